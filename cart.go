@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Cart is the list of actions picked for a policy. It is saved after every change, so it survives restarts.
+type Cart struct {
+	path    string
+	Actions []*ServiceAction
+	// names of actions in the saved cart which aren't documented (anymore), kept so saving doesn't lose them
+	Unknown []string
+}
+
+// CartGroup is the actions of the cart with the same service and access level.
+type CartGroup struct {
+	Service     *Service
+	AccessLevel string
+	Actions     []*ServiceAction
+}
+
+type cartFile struct {
+	Actions []string
+}
+
+// getCartPath returns the path of the file the cart is saved in. The cart is state rather than cached data, so unless
+// the data directory was given explicitly it goes to the XDG state directory.
+func getCartPath(opts *Options) (string, error) {
+	dir := opts.DataDir
+	if len(dir) == 0 {
+		var err error
+		dir, err = getUserDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+		if err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, CART_PATH), nil
+}
+
+func loadCart(path string, services []*Service) (*Cart, error) {
+	cart := &Cart{path: path, Actions: make([]*ServiceAction, 0), Unknown: make([]string, 0)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cart, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	saved := &cartFile{}
+	err = json.Unmarshal(data, saved)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, name := range saved.Actions {
+		service, action, err := findAction(name, services)
+		if err != nil {
+			cart.Unknown = append(cart.Unknown, name)
+			continue
+		}
+		cart.Actions = append(cart.Actions, &ServiceAction{Service: service, Action: action})
+	}
+	return cart, nil
+}
+
+func (c *Cart) save() error {
+	saved := &cartFile{Actions: append([]string{}, c.Unknown...)}
+	for _, it := range c.Actions {
+		saved.Actions = append(saved.Actions, it.FullName())
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
+
+func (c *Cart) Contains(serviceAction *ServiceAction) bool {
+	for _, it := range c.Actions {
+		if it.FullName() == serviceAction.FullName() {
+			return true
+		}
+	}
+	return false
+}
+
+// Toggle adds the action to the cart if it isn't in it yet, otherwise removes it.
+func (c *Cart) Toggle(serviceAction *ServiceAction) error {
+	c.Actions = toggleServiceAction(c.Actions, serviceAction)
+	return c.save()
+}
+
+func (c *Cart) Remove(serviceAction *ServiceAction) error {
+	if !c.Contains(serviceAction) {
+		return nil
+	}
+	return c.Toggle(serviceAction)
+}
+
+func (c *Cart) Clear() error {
+	c.Actions = make([]*ServiceAction, 0)
+	c.Unknown = make([]string, 0)
+	return c.save()
+}
+
+// Groups returns the actions of the cart grouped by service, sorted by name, and by access level.
+func (c *Cart) Groups() []*CartGroup {
+	byService := map[string][]*ServiceAction{}
+	services := make([]*Service, 0)
+	for _, it := range c.Actions {
+		if _, ok := byService[it.Service.Prefix]; !ok {
+			services = append(services, it.Service)
+		}
+		byService[it.Service.Prefix] = append(byService[it.Service.Prefix], it)
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	groups := make([]*CartGroup, 0)
+	for _, service := range services {
+		serviceActions := append([]*ServiceAction{}, byService[service.Prefix]...)
+		sort.SliceStable(serviceActions, func(i, j int) bool {
+			return strings.ToLower(serviceActions[i].Action.Name) < strings.ToLower(serviceActions[j].Action.Name)
+		})
+		accessLevels, byAccessLevel := groupByAccessLevel(serviceActions)
+		for _, accessLevel := range accessLevels {
+			groups = append(groups, &CartGroup{Service: service, AccessLevel: accessLevel, Actions: byAccessLevel[accessLevel]})
+		}
+	}
+	return groups
+}
+
+// toggleServiceAction adds the action to the list if it isn't in it yet, otherwise removes it.
+func toggleServiceAction(serviceActions []*ServiceAction, serviceAction *ServiceAction) []*ServiceAction {
+	out := make([]*ServiceAction, 0, len(serviceActions)+1)
+	for _, it := range serviceActions {
+		if it.FullName() != serviceAction.FullName() {
+			out = append(out, it)
+		}
+	}
+	if len(out) == len(serviceActions) {
+		out = append(out, serviceAction)
+	}
+	return out
+}
+
+// renderCartList renders the names of the actions in the cart, one per line, sorted.
+func renderCartList(cart *Cart) string {
+	names := make([]string, 0, len(cart.Actions))
+	for _, it := range cart.Actions {
+		names = append(names, it.FullName())
+	}
+	sort.Strings(names)
+	return strings.Join(names, "\n")
+}
+
+func runCart(opts *Options, args []string) error {
+	flags := newFlagSet("cart", "cart [--output policy|list] [--clear]")
+	output := flags.String("output", "policy", "output format, either policy or list")
+	clearCart := flags.Bool("clear", false, "empty the cart instead of printing it")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+	if *output != "policy" && *output != "list" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	path, err := getCartPath(opts)
+	if err != nil {
+		return err
+	}
+	if *clearCart {
+		// Clearing doesn't need the IAM data, which might have to be crawled first
+		return (&Cart{path: path}).Clear()
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+	cart, err := loadCart(path, services)
+	if err != nil {
+		return err
+	}
+	for _, it := range cart.Unknown {
+		fmt.Fprintf(os.Stderr, "warning: the cart contains the undocumented action %s\n", it)
+	}
+
+	text := renderCartList(cart)
+	if *output == "policy" {
+		text, err = renderPolicy(buildPolicy(cart.Actions, opts.Placeholders))
+		if err != nil {
+			return err
+		}
+	} else if len(text) == 0 {
+		return nil
+	}
+	_, err = fmt.Fprintln(os.Stdout, text)
+	return err
+}
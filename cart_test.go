@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCart(t *testing.T) {
+	services := testServices()
+	path := filepath.Join(t.TempDir(), "dir", CART_PATH)
+
+	cart, err := loadCart(path, services)
+	assert.NoError(t, err)
+	assert.Empty(t, cart.Actions)
+
+	for _, it := range []string{"s3:PutObject", "iam:PassRole", "s3:GetObject", "s3:ListBucket"} {
+		service, action, err := findAction(it, services)
+		assert.NoError(t, err)
+		assert.NoError(t, cart.Toggle(&ServiceAction{Service: service, Action: action}))
+	}
+	assert.NoError(t, cart.Toggle(cart.Actions[3]))
+	assert.Equal(t, "iam:PassRole\ns3:GetObject\ns3:PutObject", renderCartList(cart))
+
+	cart, err = loadCart(path, services)
+	assert.NoError(t, err)
+	assert.Equal(t, "iam:PassRole\ns3:GetObject\ns3:PutObject", renderCartList(cart))
+
+	assert.NoError(t, cart.Remove(cart.Actions[0]))
+	assert.NoError(t, cart.Remove(&ServiceAction{Service: services[0], Action: &Action{Name: "ListBucket"}}))
+	assert.Equal(t, "iam:PassRole\ns3:GetObject", renderCartList(cart))
+}
+
+func TestCartKeepsUnknownActions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CART_PATH)
+	assert.NoError(t, os.WriteFile(path, []byte(`{"Actions": ["ec2:RunInstances", "s3:getobject"]}`), 0644))
+
+	cart, err := loadCart(path, testServices())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ec2:RunInstances"}, cart.Unknown)
+	assert.Equal(t, "s3:GetObject", renderCartList(cart))
+
+	assert.NoError(t, cart.save())
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Actions": ["ec2:RunInstances", "s3:GetObject"]}`, string(data))
+}
+
+func TestRunCartClearDoesNotLoadServices(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, CART_PATH)
+	assert.NoError(t, os.WriteFile(path, []byte(`{"Actions": ["s3:GetObject"]}`), 0644))
+
+	opts := &Options{DataDir: dir, Crawler: func() ([]*Service, []*CrawlError, error) {
+		t.Fatal("the cart must be cleared without crawling")
+		return nil, nil, nil
+	}}
+	assert.NoError(t, runCart(opts, []string{"--clear"}))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Actions": []}`, string(data))
+}
+
+func TestGetCartPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+
+	path, err := getCartPath(&Options{DataDir: "/data"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/data", CART_PATH), path)
+
+	path, err = getCartPath(&Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".local", "state", XDG_PROJECT_DIR, CART_PATH), path)
+
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	path, err = getCartPath(&Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(state, XDG_PROJECT_DIR, CART_PATH), path)
+
+	// Kept with the data that was there before the XDG directories
+	assert.NoError(t, os.Mkdir(filepath.Join(home, PROJECT_DIR), 0755))
+	path, err = getCartPath(&Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, PROJECT_DIR, CART_PATH), path)
+}
+
+func TestCartGroups(t *testing.T) {
+	services := testServices()
+	cart := &Cart{Actions: expandWildcard("*", services)}
+
+	groups := []string{}
+	for _, group := range cart.Groups() {
+		names := []string{}
+		for _, it := range group.Actions {
+			names = append(names, it.Action.Name)
+		}
+		groups = append(groups, group.Service.Prefix+" "+group.AccessLevel+": "+strings.Join(names, ", "))
+	}
+	assert.Equal(t, []string{
+		"iam Write: PassRole",
+		"iam Permissions management: AttachRolePolicy",
+		"s3 List: ListBucket",
+		"s3 Read: GetObject",
+		"s3 Write: PutObject",
+	}, groups)
+}
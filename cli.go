@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const USAGE = `Usage: iampolicyhelper [flags] [command]
+
+Commands:
+  (none)                        start the interactive search
+  lookup <prefix:action>        print the details of an action as text or JSON
+  policy <prefix:action>...     print a policy document allowing the actions
+  expand <pattern>              list the actions matching a wildcard like s3:Get*
+  lint <policy.json>...         check the actions and condition keys of policies
+  search <words>...             search actions by their name and description
+  list                          list the actions of a service, optionally by access level
+  resource <arn|prefix:type>    list the actions that can be scoped to a resource
+  condition <condition-key>     list the actions supporting a condition key
+  cart                          print the actions added to the cart as a policy or list
+  diff <old.json> <new.json>    print what changed between two crawls
+  snapshot list|prune           list or delete the snapshots of previous crawls
+  refresh                       crawl the IAM data again and replace the local data
+  embed --out <file>            write the local data to the file that -tags embeddata builds into the binary
+  crawl                         crawl the IAM data, optionally from a local copy
+
+Flags:
+`
+
+const ON_STALE_WARN = "warn"
+const ON_STALE_RECRAWL = "recrawl"
+
+// Options holds the flags that apply to every command.
+type Options struct {
+	Recrawl      bool
+	MaxAge       time.Duration
+	OnStale      string
+	Placeholders ARNPlaceholders
+	Clipboard    Clipboard
+	// the terminal of the interactive search, which OSC 52 escape sequences are written to
+	Terminal *screenTerminal
+	Snapshot string
+	Crawler  Crawler
+	DataDir  string
+	DataFile string
+}
+
+// run parses the global flags and dispatches to the subcommand named by the first remaining argument. The
+// interactive TUI is started when no subcommand is given.
+func run(args []string) error {
+	opts := &Options{}
+	flags := flag.NewFlagSet("iampolicyhelper", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, USAGE)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&opts.Recrawl, "recrawl", false, "crawl the IAM documentation again before running the command")
+	maxAge := flags.String("max-age", envOr("IAMPOLICYHELPER_MAX_AGE", "30d"), "age (e.g. 30d or 12h) after which the local data is stale, 0 to disable (env IAMPOLICYHELPER_MAX_AGE)")
+	flags.StringVar(&opts.OnStale, "on-stale", envOr("IAMPOLICYHELPER_ON_STALE", ON_STALE_WARN), "what to do with stale data, either warn or recrawl (env IAMPOLICYHELPER_ON_STALE)")
+	flags.StringVar(&opts.Placeholders.Partition, "partition", "aws", "partition to fill into the ARNs of generated policies")
+	flags.StringVar(&opts.Placeholders.Region, "region", "*", "region to fill into the ARNs of generated policies")
+	flags.StringVar(&opts.Placeholders.Account, "account", "*", "account ID to fill into the ARNs of generated policies")
+	clipboard := flags.String("clipboard", envOr("IAMPOLICYHELPER_CLIPBOARD", CLIPBOARD_AUTO), "where the interactive search copies to, either auto, system, osc52, or file:<path> (env IAMPOLICYHELPER_CLIPBOARD)")
+	source := flags.String("source", envOr("IAMPOLICYHELPER_SOURCE", SOURCE_DOCS), "where crawls get the IAM data from, either docs, docs-dir:<dir>, service-reference:<path>, or policies-js:<path> (env IAMPOLICYHELPER_SOURCE)")
+	flags.StringVar(&opts.DataDir, "data-dir", envOr("IAMPOLICYHELPER_HOME", ""), "directory to keep the local data and the cart in instead of the XDG cache and state directories (env IAMPOLICYHELPER_HOME)")
+	flags.StringVar(&opts.DataFile, "data-file", envOr("IAMPOLICYHELPER_DATA_FILE", ""), "read the IAM data from this file, e.g. a copy of rawData.json, which is never crawled again (env IAMPOLICYHELPER_DATA_FILE)")
+	flags.StringVar(&opts.Snapshot, "snapshot", "", "use the data of this snapshot instead of the latest crawl, either its ID or a prefix like 2024-05-01")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	args = flags.Args()
+
+	opts.MaxAge, err = parseAge(*maxAge)
+	if err != nil {
+		return fmt.Errorf("invalid max age %q: %w", *maxAge, err)
+	}
+	if opts.OnStale != ON_STALE_WARN && opts.OnStale != ON_STALE_RECRAWL {
+		return fmt.Errorf("invalid stale data behavior %q: expected %s or %s", opts.OnStale, ON_STALE_WARN, ON_STALE_RECRAWL)
+	}
+	err = checkClipboardSpec(*clipboard)
+	if err != nil {
+		return fmt.Errorf("invalid clipboard %q: %w", *clipboard, err)
+	}
+	if len(opts.DataFile) > 0 && len(opts.Snapshot) > 0 {
+		return fmt.Errorf("--data-file and --snapshot can't be used together")
+	}
+	opts.Crawler, err = newCrawler(*source)
+	if err != nil {
+		return fmt.Errorf("invalid source %q: %w", *source, err)
+	}
+
+	if len(args) == 0 {
+		services, err := loadServices(opts)
+		if err != nil {
+			return err
+		}
+		crawledAt, err := loadDataCrawledAt(opts)
+		if err != nil {
+			return err
+		}
+		cartPath, err := getCartPath(opts)
+		if err != nil {
+			return err
+		}
+		cart, err := loadCart(cartPath, services)
+		if err != nil {
+			return err
+		}
+		// Only the interactive search copies, so only it needs a clipboard
+		opts.Terminal = &screenTerminal{}
+		opts.Clipboard, err = newClipboard(*clipboard, opts.Terminal)
+		if err != nil {
+			return fmt.Errorf("invalid clipboard %q: %w", *clipboard, err)
+		}
+		return runTUI(services, crawledAt, cart, opts)
+	}
+
+	switch args[0] {
+	case "lookup":
+		return runLookup(opts, args[1:])
+	case "refresh":
+		return runRefresh(opts, args[1:])
+	case "policy":
+		return runPolicy(opts, args[1:])
+	case "expand":
+		return runExpand(opts, args[1:])
+	case "search":
+		return runSearch(opts, args[1:])
+	case "resource":
+		return runResource(opts, args[1:])
+	case "condition":
+		return runConditionKey(opts, args[1:])
+	case "list":
+		return runList(opts, args[1:])
+	case "cart":
+		return runCart(opts, args[1:])
+	case "diff":
+		return runDiff(opts, args[1:])
+	case "snapshot":
+		return runSnapshot(opts, args[1:])
+	case "embed":
+		return runEmbed(opts, args[1:])
+	case "lint":
+		return runLint(opts, args[1:])
+	case "crawl":
+		return runCrawl(opts, args[1:])
+	case "help":
+		flags.Usage()
+		return flag.ErrHelp
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func envOr(key string, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if ok && len(value) > 0 {
+		return value
+	}
+	return fallback
+}
+
+// parseAge parses a duration like time.ParseDuration does, additionally accepting a number of days like "30d".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: iampolicyhelper %s\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func runRefresh(opts *Options, args []string) error {
+	flags := newFlagSet("refresh", "refresh [--diff]")
+	printDiff := flags.Bool("diff", false, "print what changed compared to the replaced data")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	projectDir, err := getProjectDir(opts)
+	if err != nil {
+		return err
+	}
+
+	oldServices := make([]*Service, 0)
+	if *printDiff {
+		// Without local data, everything is new
+		oldServices, err = loadRawData(projectDir, "")
+		if errors.Is(err, os.ErrNotExist) {
+			oldServices = make([]*Service, 0)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	services, err := crawlAndSave(projectDir, opts.Crawler)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved %d services with %d actions\n", len(services), len(buildActionNames(services)))
+
+	if *printDiff {
+		_, err = fmt.Fprintln(os.Stdout, renderDiff(diffServices(oldServices, services)))
+	}
+	return err
+}
+
+func runCrawl(opts *Options, args []string) error {
+	flags := newFlagSet("crawl", "crawl [--from-dir <path>] [--out <file>]")
+	fromDir := flags.String("from-dir", "", "crawl the saved documentation pages in this directory instead of the --source")
+	out := flags.String("out", "", "write the crawled data to this file instead of replacing the local data")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	crawler := opts.Crawler
+	if len(*fromDir) > 0 {
+		crawler = func() ([]*Service, []*CrawlError, error) {
+			return crawlFromDir(*fromDir)
+		}
+	}
+
+	var services []*Service
+	if len(*out) > 0 {
+		var crawlErrors []*CrawlError
+		services, crawlErrors, err = crawler()
+		if err == nil {
+			reportCrawlErrors(os.Stderr, crawlErrors)
+			err = checkCrawl(services, crawlErrors)
+		}
+		if err == nil {
+			err = saveCrawl(services, *out)
+		}
+		if err == nil {
+			err = writeCrawledAt(time.Now(), dataFileCrawledAtPath(*out))
+		}
+	} else {
+		var projectDir string
+		projectDir, err = getProjectDir(opts)
+		if err == nil {
+			services, err = crawlAndSave(projectDir, crawler)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved %d services with %d actions\n", len(services), len(buildActionNames(services)))
+	return nil
+}
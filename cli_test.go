@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAge(t *testing.T) {
+	age, err := parseAge("30d")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, age)
+
+	age, err = parseAge("12h")
+	assert.NoError(t, err)
+	assert.Equal(t, 12*time.Hour, age)
+
+	age, err = parseAge("0")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), age)
+
+	_, err = parseAge("xd")
+	assert.Error(t, err)
+}
+
+func TestRunCrawlOut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "iam.json")
+	assert.NoError(t, runCrawl(&Options{}, []string{"--from-dir", filepath.Join("testdata", "docs"), "--out", path}))
+
+	services, err := readServices(path)
+	assert.NoError(t, err)
+	assert.Len(t, services, 2)
+	_, err = loadDataCrawledAt(&Options{DataFile: path})
+	assert.NoError(t, err)
+	_, err = os.Stat(path + DATA_FILE_CRAWLED_AT_SUFFIX)
+	assert.NoError(t, err)
+
+	// Nothing is written if nothing was crawled
+	empty := filepath.Join(t.TempDir(), "empty.json")
+	offline := &Options{Crawler: func() ([]*Service, []*CrawlError, error) {
+		return []*Service{}, nil, nil
+	}}
+	assert.ErrorContains(t, runCrawl(offline, []string{"--out", empty}), "no services were crawled")
+	_, err = os.Stat(empty)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(empty + DATA_FILE_CRAWLED_AT_SUFFIX)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+)
+
+const CLIPBOARD_AUTO = "auto"
+const CLIPBOARD_SYSTEM = "system"
+const CLIPBOARD_OSC52 = "osc52"
+const CLIPBOARD_FILE_PREFIX = "file:"
+
+// Clipboard puts text where the user can paste it from.
+type Clipboard func(text string) error
+
+// checkClipboardSpec checks the syntax of a clipboard spec without looking for the clipboard, which only the
+// interactive search needs.
+func checkClipboardSpec(spec string) error {
+	switch {
+	case spec == CLIPBOARD_AUTO || spec == CLIPBOARD_SYSTEM || spec == CLIPBOARD_OSC52:
+		return nil
+	case strings.HasPrefix(spec, CLIPBOARD_FILE_PREFIX) && len(spec) > len(CLIPBOARD_FILE_PREFIX):
+		return nil
+	default:
+		return fmt.Errorf("expected %s, %s, %s, or %s<path>", CLIPBOARD_AUTO, CLIPBOARD_SYSTEM, CLIPBOARD_OSC52, CLIPBOARD_FILE_PREFIX)
+	}
+}
+
+// newClipboard creates the clipboard described by spec: the system clipboard, the terminal's clipboard through OSC 52
+// escape sequences written to terminal, a file like "file:/tmp/copied.txt", or "auto" for the system clipboard if there
+// is one and the terminal's otherwise.
+func newClipboard(spec string, terminal io.Writer) (Clipboard, error) {
+	err := checkClipboardSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	switch spec {
+	case CLIPBOARD_AUTO:
+		if command := systemClipboardCommand(); command != nil {
+			return commandClipboard(command), nil
+		}
+		return osc52Clipboard(terminal), nil
+	case CLIPBOARD_SYSTEM:
+		command := systemClipboardCommand()
+		if command == nil {
+			return nil, fmt.Errorf("no system clipboard found, install one of pbcopy, wl-copy, xclip, or xsel")
+		}
+		return commandClipboard(command), nil
+	case CLIPBOARD_OSC52:
+		return osc52Clipboard(terminal), nil
+	default:
+		return fileClipboard(strings.TrimPrefix(spec, CLIPBOARD_FILE_PREFIX)), nil
+	}
+}
+
+// systemClipboardCommand returns the command copying its stdin to the system clipboard, or nil if there is none.
+func systemClipboardCommand() []string {
+	candidates := [][]string{{"pbcopy"}, {"clip.exe"}}
+	if len(os.Getenv("WAYLAND_DISPLAY")) > 0 {
+		candidates = append(candidates, []string{"wl-copy"})
+	}
+	if len(os.Getenv("DISPLAY")) > 0 {
+		candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	for _, it := range candidates {
+		if _, err := exec.LookPath(it[0]); err == nil {
+			return it
+		}
+	}
+	return nil
+}
+
+func commandClipboard(command []string) Clipboard {
+	return func(text string) error {
+		// xclip and wl-copy leave a child running to serve the clipboard, which would keep output pipes open, so only
+		// stdin is connected
+		cmd := exec.Command(command[0], command[1:]...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		err = cmd.Start()
+		if err != nil {
+			return fmt.Errorf("%s: %w", command[0], err)
+		}
+		_, err = io.WriteString(stdin, text)
+		closeErr := stdin.Close()
+		if err == nil {
+			err = closeErr
+		}
+		waitErr := cmd.Wait()
+		if err == nil {
+			err = waitErr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", command[0], err)
+		}
+		return nil
+	}
+}
+
+// osc52Clipboard asks the terminal to put the text into the clipboard, which also works over SSH.
+func osc52Clipboard(w io.Writer) Clipboard {
+	return func(text string) error {
+		sequence := fmt.Sprintf("\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+		if len(os.Getenv("TMUX")) > 0 {
+			// tmux only passes escape sequences on to the terminal when they are wrapped
+			sequence = fmt.Sprintf("\x1bPtmux;\x1b%s\x1b\\", sequence)
+		}
+		_, err := io.WriteString(w, sequence)
+		return err
+	}
+}
+
+// screenTerminal writes to the terminal the interactive search draws on. It is only written to by key handlers, which
+// run between the draws of the application, so the escape sequences never end up in the middle of a draw.
+type screenTerminal struct {
+	app *cview.Application
+}
+
+func (it *screenTerminal) Write(p []byte) (int, error) {
+	if it.app == nil || it.app.GetScreen() == nil {
+		return 0, errors.New("the interactive search isn't running")
+	}
+	tty, ok := it.app.GetScreen().Tty()
+	if !ok {
+		return 0, errors.New("the screen has no terminal to write to")
+	}
+	return tty.Write(p)
+}
+
+func fileClipboard(path string) Clipboard {
+	return func(text string) error {
+		return os.WriteFile(path, []byte(text), 0644)
+	}
+}
+
+// statementSnippet renders the statement allowing the action, ready to paste into the Statement list of a policy.
+func statementSnippet(serviceAction *ServiceAction, placeholders ARNPlaceholders) (string, error) {
+	policy := buildPolicy([]*ServiceAction{serviceAction}, placeholders)
+	data, err := json.MarshalIndent(policy.Statement[0], "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClipboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "copied.txt")
+	clipboard, err := newClipboard("file:"+path, io.Discard)
+	assert.NoError(t, err)
+	assert.NoError(t, clipboard("s3:GetObject"))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3:GetObject", string(data))
+
+	_, err = newClipboard("file:", io.Discard)
+	assert.Error(t, err)
+	_, err = newClipboard("clipboard", io.Discard)
+	assert.Error(t, err)
+}
+
+func TestRunOnlyChecksClipboardSpec(t *testing.T) {
+	// No clipboard tool is found, which only matters to the interactive search
+	t.Setenv("PATH", "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	path := filepath.Join(t.TempDir(), "iam.json")
+	assert.NoError(t, saveCrawl(testServices(), path))
+
+	assert.NoError(t, run([]string{"--clipboard", "system", "--data-file", path, "expand", "s3:Get*"}))
+	assert.ErrorContains(t, run([]string{"--clipboard", "clipboard", "--data-file", path, "expand", "s3:Get*"}), "invalid clipboard")
+}
+
+func TestCommandClipboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "copied.txt")
+	// Like xclip, leave a child running that would keep output pipes open
+	clipboard := commandClipboard([]string{"sh", "-c", `cat > "$0"; sleep 10 &`, path})
+	start := time.Now()
+	assert.NoError(t, clipboard("s3:GetObject"))
+	assert.Less(t, time.Since(start), 5*time.Second)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3:GetObject", string(data))
+
+	assert.Error(t, commandClipboard([]string{"false"})("s3:GetObject"))
+}
+
+func TestOSC52Clipboard(t *testing.T) {
+	t.Setenv("TMUX", "")
+	out := &strings.Builder{}
+	assert.NoError(t, osc52Clipboard(out)("s3:GetObject"))
+	assert.Equal(t, "\x1b]52;c;czM6R2V0T2JqZWN0\a", out.String())
+
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	out.Reset()
+	assert.NoError(t, osc52Clipboard(out)("s3:GetObject"))
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;czM6R2V0T2JqZWN0\a\x1b\\", out.String())
+
+	// The terminal of the interactive search can only be written to while it runs
+	assert.Error(t, osc52Clipboard(&screenTerminal{})("s3:GetObject"))
+}
+
+func TestStatementSnippet(t *testing.T) {
+	services := testServices()
+	snippet, err := statementSnippet(
+		&ServiceAction{Service: services[1], Action: services[1].Actions[1]},
+		ARNPlaceholders{Partition: "aws", Account: "123456789012"},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "Effect": "Allow",
+  "Action": [
+    "iam:PassRole"
+  ],
+  "Resource": [
+    "arn:aws:iam::123456789012:role/${RoleNameWithPath}"
+  ]
+}`, snippet)
+}
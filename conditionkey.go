@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ConditionKeyAction is an action supporting a condition key.
+type ConditionKeyAction struct {
+	ServiceAction *ServiceAction `json:"-"`
+	Action        string
+	AccessLevel   string
+	// the condition key as documented, which may end in a placeholder like "aws:ResourceTag/${TagKey}"
+	ConditionKey string
+	// the resource types through which the action supports the condition key, empty if the action supports it itself
+	ResourceTypes []string
+}
+
+// ConditionKeyLookup is the machine-readable form of everything renderConditionKey shows for a condition key.
+type ConditionKeyLookup struct {
+	Name    string
+	Global  bool
+	Actions []*ConditionKeyAction
+}
+
+func isGlobalConditionKey(name string) bool {
+	for _, it := range GLOBAL_CONDITION_KEYS {
+		if conditionKeyMatches(it, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allConditionKeyNames returns the documented names of all condition keys, sorted case-insensitively.
+func allConditionKeyNames(services []*Service) []string {
+	names := append([]string{}, GLOBAL_CONDITION_KEYS...)
+	for _, service := range services {
+		for _, conditionKey := range service.ConditionKeys {
+			names = append(names, conditionKey.Name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return unique(names)
+}
+
+// ConditionKeyIndex finds the actions supporting a condition key without going through every action of every service.
+type ConditionKeyIndex struct {
+	entries []*ServiceAction
+	// the positions in entries of the actions supporting each documented condition key, by its lowercase name
+	postings map[string][]int
+}
+
+func NewConditionKeyIndex(services []*Service) *ConditionKeyIndex {
+	index := &ConditionKeyIndex{
+		entries:  make([]*ServiceAction, 0),
+		postings: map[string][]int{},
+	}
+	for _, service := range services {
+		for _, serviceAction := range expandWildcard(service.Prefix+":*", []*Service{service}) {
+			position := len(index.entries)
+			index.entries = append(index.entries, serviceAction)
+			names := append([]string{}, serviceAction.Action.ConditionKeys...)
+			eachResourceType(service, serviceAction.Action, func(resourceType *ResourceType) {
+				names = append(names, resourceType.ConditionKeys...)
+			})
+			for _, name := range names {
+				name = strings.ToLower(name)
+				postings := index.postings[name]
+				if len(postings) == 0 || postings[len(postings)-1] != position {
+					index.postings[name] = append(postings, position)
+				}
+			}
+		}
+	}
+	return index
+}
+
+// Find returns every action supporting the condition key, either in its own condition keys or in those of one of its
+// resource types. The name may fill in the placeholder of a documented key, like "aws:ResourceTag/Owner".
+func (index *ConditionKeyIndex) Find(name string) []*ConditionKeyAction {
+	name = strings.TrimSpace(name)
+	positions := make([]int, 0)
+	for documented, postings := range index.postings {
+		if conditionKeyMatches(documented, name) {
+			positions = append(positions, postings...)
+		}
+	}
+	sort.Ints(positions)
+
+	matches := make([]*ConditionKeyAction, 0, len(positions))
+	for i, position := range positions {
+		if i > 0 && positions[i-1] == position {
+			continue
+		}
+		if match := newConditionKeyAction(index.entries[position], name); match != nil {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// newConditionKeyAction describes how the action supports the condition key, or returns nil if it doesn't.
+func newConditionKeyAction(serviceAction *ServiceAction, name string) *ConditionKeyAction {
+	match := &ConditionKeyAction{
+		ServiceAction: serviceAction,
+		Action:        serviceAction.FullName(),
+		AccessLevel:   serviceAction.Action.AccessLevel,
+		ResourceTypes: make([]string, 0),
+	}
+	supported := false
+	for _, it := range serviceAction.Action.ConditionKeys {
+		if conditionKeyMatches(it, name) {
+			supported = true
+			match.ConditionKey = it
+		}
+	}
+	eachResourceType(serviceAction.Service, serviceAction.Action, func(resourceType *ResourceType) {
+		for _, it := range resourceType.ConditionKeys {
+			if conditionKeyMatches(it, name) {
+				match.ConditionKey = it
+				match.ResourceTypes = append(match.ResourceTypes, resourceType.Name)
+			}
+		}
+	})
+	if supported {
+		// The action supports the key for every resource, so the resource types don't matter
+		match.ResourceTypes = match.ResourceTypes[:0]
+	}
+	if !supported && len(match.ResourceTypes) == 0 {
+		return nil
+	}
+	match.ResourceTypes = unique(match.ResourceTypes)
+	return match
+}
+
+// findActionsByConditionKey looks up a single condition key, see ConditionKeyIndex.Find.
+func findActionsByConditionKey(name string, services []*Service) []*ConditionKeyAction {
+	return NewConditionKeyIndex(services).Find(name)
+}
+
+func newConditionKeyLookup(name string, index *ConditionKeyIndex) *ConditionKeyLookup {
+	return &ConditionKeyLookup{
+		Name:    name,
+		Global:  isGlobalConditionKey(name),
+		Actions: index.Find(name),
+	}
+}
+
+func renderConditionKey(lookup *ConditionKeyLookup) string {
+	message := fmt.Sprintf("[::b]%s[::-] is supported by %d actions", cview.Escape(lookup.Name), len(lookup.Actions))
+	if lookup.Global {
+		message = fmt.Sprintf("[::b]%s[::-] is a global condition key and available in every request", cview.Escape(lookup.Name))
+	}
+	if len(lookup.Actions) == 0 {
+		return message
+	}
+
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"Action", "Access Level", "Resource Types"})
+	table.SetColWidth(100)
+	for _, it := range lookup.Actions {
+		table.Append([]string{it.Action, it.AccessLevel, strings.Join(it.ResourceTypes, ", ")})
+	}
+	table.Render()
+	return fmt.Sprintf("%s\n\n%s", message, tableString)
+}
+
+func runConditionKey(opts *Options, args []string) error {
+	flags := newFlagSet("condition", "condition [--output text|json] <condition-key>")
+	output := flags.String("output", "text", "output format, either text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one condition key, got %d", flags.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+
+	lookup := newConditionKeyLookup(flags.Arg(0), NewConditionKeyIndex(services))
+	if !lookup.Global && len(lookup.Actions) == 0 {
+		return fmt.Errorf("no action supports condition key %q", flags.Arg(0))
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(lookup)
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, formatForOutput(renderConditionKey(lookup), os.Stdout))
+	return err
+}
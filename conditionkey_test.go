@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindActionsByConditionKey(t *testing.T) {
+	services := testServices()
+
+	matches := findActionsByConditionKey("S3:Prefix", services)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "s3:ListBucket", matches[0].Action)
+	assert.Equal(t, "s3:prefix", matches[0].ConditionKey)
+	assert.Empty(t, matches[0].ResourceTypes)
+
+	// Supported through the resource type, and the tag key fills in the placeholder
+	matches = findActionsByConditionKey("aws:ResourceTag/Owner", services)
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "iam:AttachRolePolicy", matches[0].Action)
+	assert.Equal(t, "aws:ResourceTag/${TagKey}", matches[0].ConditionKey)
+	assert.Equal(t, []string{"role"}, matches[0].ResourceTypes)
+
+	// GetObject spans two rows, one of which lists the key
+	matches = findActionsByConditionKey("s3:signatureversion", services)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "s3:GetObject", matches[0].Action)
+
+	assert.Empty(t, findActionsByConditionKey("s3:nothing", services))
+}
+
+func TestNewConditionKeyLookup(t *testing.T) {
+	lookup := newConditionKeyLookup("aws:SourceIp", NewConditionKeyIndex(testServices()))
+	assert.True(t, lookup.Global)
+	assert.Empty(t, lookup.Actions)
+
+	assert.False(t, newConditionKeyLookup("aws:ResourceTag/${TagKey}", NewConditionKeyIndex(testServices())).Global)
+}
+
+func TestAllConditionKeyNames(t *testing.T) {
+	names := allConditionKeyNames(testServices())
+	assert.Contains(t, names, "aws:ResourceTag/${TagKey}")
+	assert.Contains(t, names, "aws:SourceIp")
+	assert.Equal(t, len(unique(names)), len(names))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matches the IDs of cview regions
+var regionTagPattern = regexp.MustCompile(`\["([^"]+)"\]`)
+
+// DependentAction is an action that has to be allowed too for another action to succeed.
+type DependentAction struct {
+	Name string
+	// the action, or nil if it isn't documented
+	ServiceAction *ServiceAction
+	// the action listing this one as dependent action
+	RequiredBy string
+}
+
+// dependencyClosure returns every action needed to perform the action, in the order they are found by following the
+// dependent actions breadth first.
+func dependencyClosure(serviceAction *ServiceAction, services []*Service) []*DependentAction {
+	closure := make([]*DependentAction, 0)
+	seen := map[string]bool{strings.ToLower(serviceAction.FullName()): true}
+	queue := []*ServiceAction{serviceAction}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, name := range unique(current.Action.DependentActions) {
+			if seen[strings.ToLower(name)] {
+				continue
+			}
+			seen[strings.ToLower(name)] = true
+
+			dependentAction := &DependentAction{Name: name, RequiredBy: current.FullName()}
+			service, action, err := findAction(name, services)
+			if err == nil {
+				dependentAction.ServiceAction = &ServiceAction{Service: service, Action: action}
+				dependentAction.Name = dependentAction.ServiceAction.FullName()
+				queue = append(queue, dependentAction.ServiceAction)
+			}
+			closure = append(closure, dependentAction)
+		}
+	}
+	return closure
+}
+
+// renderActionLink renders the name of an action as a region, so it can be selected in the text view to navigate to
+// the action.
+func renderActionLink(fullActionName string) string {
+	return fmt.Sprintf(`["%s"][::u]%s[::-][""]`, fullActionName, fullActionName)
+}
+
+func renderDependentActions(dependentActionNames []string) string {
+	links := make([]string, 0, len(dependentActionNames))
+	for _, it := range unique(dependentActionNames) {
+		links = append(links, renderActionLink(it))
+	}
+	return strings.Join(links, ", ")
+}
+
+func renderDependencyClosure(serviceAction *ServiceAction, closure []*DependentAction) string {
+	if len(closure) == 0 {
+		return fmt.Sprintf("[::b]%s[::-] has no dependent actions", serviceAction.FullName())
+	}
+
+	lines := make([]string, 0, len(closure))
+	for _, it := range closure {
+		if it.ServiceAction == nil {
+			lines = append(lines, fmt.Sprintf("%s [::d](undocumented)[::-], required by %s", it.Name, it.RequiredBy))
+		} else {
+			lines = append(lines, fmt.Sprintf(
+				"%s [::d](%s)[::-], required by %s",
+				renderActionLink(it.Name),
+				it.ServiceAction.Action.AccessLevel,
+				it.RequiredBy,
+			))
+		}
+	}
+	return fmt.Sprintf(
+		"[::b]%s[::-] needs %d more actions to succeed\n\n%s",
+		serviceAction.FullName(),
+		len(closure),
+		strings.Join(lines, "\n"),
+	)
+}
+
+// regionIDs returns the IDs of the regions in the text, in order.
+func regionIDs(text string) []string {
+	ids := make([]string, 0)
+	for _, match := range regionTagPattern.FindAllStringSubmatch(text, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyClosure(t *testing.T) {
+	services := testServices()
+	services[1].Actions[0].DependentActions = []string{"iam:PassRole"}
+	// Undocumented actions are reported, cycles are followed once, and names are case-insensitive
+	services[1].Actions[1].DependentActions = []string{"ec2:RunInstances", "IAM:AttachRolePolicy", "s3:getobject"}
+
+	_, action, err := findAction("iam:AttachRolePolicy", services)
+	assert.NoError(t, err)
+	closure := dependencyClosure(&ServiceAction{Service: services[1], Action: action}, services)
+
+	names := []string{}
+	for _, it := range closure {
+		names = append(names, it.Name+" <- "+it.RequiredBy)
+	}
+	assert.Equal(t, []string{
+		"iam:PassRole <- iam:AttachRolePolicy",
+		"ec2:RunInstances <- iam:PassRole",
+		"s3:GetObject <- iam:PassRole",
+	}, names)
+	assert.Nil(t, closure[1].ServiceAction)
+	assert.Equal(t, "Read", closure[2].ServiceAction.Action.AccessLevel)
+}
+
+func TestRenderDependentActions(t *testing.T) {
+	rendered := renderDependentActions([]string{"iam:TagRole", "iam:TagRole", "s3:GetObject"})
+	assert.Equal(t, `["iam:TagRole"][::u]iam:TagRole[::-][""], ["s3:GetObject"][::u]s3:GetObject[::-][""]`, rendered)
+	assert.Equal(t, []string{"iam:TagRole", "s3:GetObject"}, regionIDs(rendered))
+}
+
+func TestRenderBodyDependentActions(t *testing.T) {
+	services := testServices()
+	action := &Action{Name: "CreateRole", DependentActions: []string{"iam:TagRole"}}
+
+	rendered := renderBody(action, services[1])
+	assert.Contains(t, rendered, `[::b]Dependent Actions[::-]: ["iam:TagRole"][::u]iam:TagRole[::-][""]`)
+	assert.Contains(t, formatForOutput(rendered, nil), "Dependent Actions: iam:TagRole")
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DataDiff is what changed in the IAM documentation between two crawls.
+type DataDiff struct {
+	AddedServices   []string
+	RemovedServices []string
+	ChangedServices []*ServiceDiff
+}
+
+// ServiceDiff is what changed in a service that is in both crawls. Actions, resource types, and condition keys are
+// identified by their name.
+type ServiceDiff struct {
+	Prefix               string
+	Name                 string
+	AddedActions         []string
+	RemovedActions       []string
+	ChangedAccessLevels  []*Change
+	AddedResourceTypes   []string
+	RemovedResourceTypes []string
+	ChangedARNs          []*Change
+	AddedConditionKeys   []string
+	RemovedConditionKeys []string
+	// the condition keys whose type changed, like from String to ArrayOfString
+	ChangedConditionKeyTypes []*Change
+}
+
+type Change struct {
+	Name string
+	Old  string
+	New  string
+}
+
+func (d *DataDiff) IsEmpty() bool {
+	return len(d.AddedServices) == 0 && len(d.RemovedServices) == 0 && len(d.ChangedServices) == 0
+}
+
+func (d *ServiceDiff) IsEmpty() bool {
+	return len(d.AddedActions) == 0 && len(d.RemovedActions) == 0 && len(d.ChangedAccessLevels) == 0 &&
+		len(d.AddedResourceTypes) == 0 && len(d.RemovedResourceTypes) == 0 && len(d.ChangedARNs) == 0 &&
+		len(d.AddedConditionKeys) == 0 && len(d.RemovedConditionKeys) == 0 && len(d.ChangedConditionKeyTypes) == 0
+}
+
+// diffServices compares two crawls. Services are identified by their prefix.
+func diffServices(oldServices []*Service, newServices []*Service) *DataDiff {
+	diff := &DataDiff{
+		AddedServices:   make([]string, 0),
+		RemovedServices: make([]string, 0),
+		ChangedServices: make([]*ServiceDiff, 0),
+	}
+	oldByPrefix := servicesByPrefix(oldServices)
+	newByPrefix := servicesByPrefix(newServices)
+
+	for _, prefix := range sortedKeys(oldByPrefix) {
+		if _, ok := newByPrefix[prefix]; !ok {
+			diff.RemovedServices = append(diff.RemovedServices, prefix)
+		}
+	}
+	for _, prefix := range sortedKeys(newByPrefix) {
+		oldService, ok := oldByPrefix[prefix]
+		if !ok {
+			diff.AddedServices = append(diff.AddedServices, prefix)
+			continue
+		}
+		serviceDiff := diffService(oldService, newByPrefix[prefix])
+		if !serviceDiff.IsEmpty() {
+			diff.ChangedServices = append(diff.ChangedServices, serviceDiff)
+		}
+	}
+	return diff
+}
+
+func diffService(oldService *Service, newService *Service) *ServiceDiff {
+	diff := &ServiceDiff{
+		Prefix:                   newService.Prefix,
+		Name:                     newService.Name,
+		ChangedAccessLevels:      make([]*Change, 0),
+		ChangedARNs:              make([]*Change, 0),
+		ChangedConditionKeyTypes: make([]*Change, 0),
+	}
+
+	// Actions spanning several table rows are merged first
+	oldAccessLevels := map[string]string{}
+	for _, it := range expandWildcard(oldService.Prefix+":*", []*Service{oldService}) {
+		oldAccessLevels[it.Action.Name] = it.Action.AccessLevel
+	}
+	newAccessLevels := map[string]string{}
+	for _, it := range expandWildcard(newService.Prefix+":*", []*Service{newService}) {
+		newAccessLevels[it.Action.Name] = it.Action.AccessLevel
+	}
+	diff.AddedActions, diff.RemovedActions = diffKeys(oldAccessLevels, newAccessLevels)
+	for _, name := range sortedKeys(newAccessLevels) {
+		if old, ok := oldAccessLevels[name]; ok && old != newAccessLevels[name] {
+			diff.ChangedAccessLevels = append(diff.ChangedAccessLevels, &Change{Name: name, Old: old, New: newAccessLevels[name]})
+		}
+	}
+
+	oldARNs := map[string]string{}
+	for _, it := range oldService.ResourceTypes {
+		oldARNs[it.Name] = it.ARN
+	}
+	newARNs := map[string]string{}
+	for _, it := range newService.ResourceTypes {
+		newARNs[it.Name] = it.ARN
+	}
+	diff.AddedResourceTypes, diff.RemovedResourceTypes = diffKeys(oldARNs, newARNs)
+	for _, name := range sortedKeys(newARNs) {
+		if old, ok := oldARNs[name]; ok && old != newARNs[name] {
+			diff.ChangedARNs = append(diff.ChangedARNs, &Change{Name: name, Old: old, New: newARNs[name]})
+		}
+	}
+
+	oldConditionKeys := map[string]string{}
+	for _, it := range oldService.ConditionKeys {
+		oldConditionKeys[it.Name] = it.Type
+	}
+	newConditionKeys := map[string]string{}
+	for _, it := range newService.ConditionKeys {
+		newConditionKeys[it.Name] = it.Type
+	}
+	diff.AddedConditionKeys, diff.RemovedConditionKeys = diffKeys(oldConditionKeys, newConditionKeys)
+	for _, name := range sortedKeys(newConditionKeys) {
+		if old, ok := oldConditionKeys[name]; ok && old != newConditionKeys[name] {
+			diff.ChangedConditionKeyTypes = append(diff.ChangedConditionKeyTypes, &Change{Name: name, Old: old, New: newConditionKeys[name]})
+		}
+	}
+
+	return diff
+}
+
+func servicesByPrefix(services []*Service) map[string]*Service {
+	byPrefix := map[string]*Service{}
+	for _, it := range services {
+		byPrefix[it.Prefix] = it
+	}
+	return byPrefix
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffKeys returns the sorted keys only in newMap and those only in oldMap.
+func diffKeys[V any](oldMap map[string]V, newMap map[string]V) ([]string, []string) {
+	added := make([]string, 0)
+	for _, key := range sortedKeys(newMap) {
+		if _, ok := oldMap[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	removed := make([]string, 0)
+	for _, key := range sortedKeys(oldMap) {
+		if _, ok := newMap[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	return added, removed
+}
+
+// renderDiff renders the diff as a changelog, one change per line.
+func renderDiff(diff *DataDiff) string {
+	if diff.IsEmpty() {
+		return "No changes"
+	}
+
+	lines := make([]string, 0)
+	for _, it := range diff.AddedServices {
+		lines = append(lines, fmt.Sprintf("+ service %s", it))
+	}
+	for _, it := range diff.RemovedServices {
+		lines = append(lines, fmt.Sprintf("- service %s", it))
+	}
+	for _, service := range diff.ChangedServices {
+		lines = append(lines, fmt.Sprintf("%s (%s)", service.Prefix, service.Name))
+		for _, it := range service.AddedActions {
+			lines = append(lines, fmt.Sprintf("  + action %s:%s", service.Prefix, it))
+		}
+		for _, it := range service.RemovedActions {
+			lines = append(lines, fmt.Sprintf("  - action %s:%s", service.Prefix, it))
+		}
+		for _, it := range service.ChangedAccessLevels {
+			lines = append(lines, fmt.Sprintf("  ~ access level of %s:%s: %s -> %s", service.Prefix, it.Name, it.Old, it.New))
+		}
+		for _, it := range service.AddedResourceTypes {
+			lines = append(lines, fmt.Sprintf("  + resource type %s", it))
+		}
+		for _, it := range service.RemovedResourceTypes {
+			lines = append(lines, fmt.Sprintf("  - resource type %s", it))
+		}
+		for _, it := range service.ChangedARNs {
+			lines = append(lines, fmt.Sprintf("  ~ ARN of resource type %s: %s -> %s", it.Name, it.Old, it.New))
+		}
+		for _, it := range service.AddedConditionKeys {
+			lines = append(lines, fmt.Sprintf("  + condition key %s", it))
+		}
+		for _, it := range service.RemovedConditionKeys {
+			lines = append(lines, fmt.Sprintf("  - condition key %s", it))
+		}
+		for _, it := range service.ChangedConditionKeyTypes {
+			lines = append(lines, fmt.Sprintf("  ~ type of condition key %s: %s -> %s", it.Name, it.Old, it.New))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func runDiff(opts *Options, args []string) error {
+	flags := newFlagSet("diff", "diff [--output text|json] <old.json> <new.json>")
+	output := flags.String("output", "text", "output format, either text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly two data files, got %d", flags.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	oldServices, err := readServices(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	newServices, err := readServices(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diff := diffServices(oldServices, newServices)
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+	_, err = fmt.Fprintln(os.Stdout, renderDiff(diff))
+	return err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffServicesUnchanged(t *testing.T) {
+	diff := diffServices(testServices(), testServices())
+	assert.True(t, diff.IsEmpty())
+	assert.Equal(t, "No changes", renderDiff(diff))
+}
+
+func TestDiffServices(t *testing.T) {
+	oldServices := testServices()
+	newServices := testServices()
+
+	s3 := newServices[0]
+	s3.Actions = append(s3.Actions[:3], &Action{Name: "DeleteObject", AccessLevel: "Write"}) // ListBucket removed
+	s3.Actions[2].AccessLevel = "Permissions management"                                     // PutObject
+	s3.ResourceTypes[0].ARN = "arn:${Partition}:s3:::${BucketName}/"
+	s3.ResourceTypes = append(s3.ResourceTypes, &ResourceType{Name: "accesspoint", ARN: "arn:${Partition}:s3:${Region}:${Account}:accesspoint/${AccessPointName}"})
+	s3.ConditionKeys = append(s3.ConditionKeys, &ConditionKey{Name: "s3:TlsVersion", Type: "Numeric"})
+	s3.ConditionKeys[1].Type = "ArrayOfString"                                         // s3:prefix
+	newServices = append(newServices[:1], &Service{Name: "Amazon EC2", Prefix: "ec2"}) // iam removed
+
+	diff := diffServices(oldServices, newServices)
+	assert.Equal(t, []string{"ec2"}, diff.AddedServices)
+	assert.Equal(t, []string{"iam"}, diff.RemovedServices)
+	assert.Len(t, diff.ChangedServices, 1)
+
+	s3Diff := diff.ChangedServices[0]
+	assert.Equal(t, []string{"DeleteObject"}, s3Diff.AddedActions)
+	assert.Equal(t, []string{"ListBucket"}, s3Diff.RemovedActions)
+	assert.Equal(t, []*Change{{Name: "PutObject", Old: "Write", New: "Permissions management"}}, s3Diff.ChangedAccessLevels)
+	assert.Equal(t, []string{"accesspoint"}, s3Diff.AddedResourceTypes)
+	assert.Empty(t, s3Diff.RemovedResourceTypes)
+	assert.Equal(t, []*Change{{Name: "bucket", Old: "arn:${Partition}:s3:::${BucketName}", New: "arn:${Partition}:s3:::${BucketName}/"}}, s3Diff.ChangedARNs)
+	assert.Equal(t, []string{"s3:TlsVersion"}, s3Diff.AddedConditionKeys)
+	assert.Empty(t, s3Diff.RemovedConditionKeys)
+	assert.Equal(t, []*Change{{Name: "s3:prefix", Old: "String", New: "ArrayOfString"}}, s3Diff.ChangedConditionKeyTypes)
+
+	rendered := renderDiff(diff)
+	assert.Contains(t, rendered, "+ service ec2\n")
+	assert.Contains(t, rendered, "- service iam\n")
+	assert.Contains(t, rendered, "s3 (Amazon S3)\n")
+	assert.Contains(t, rendered, "  + action s3:DeleteObject\n")
+	assert.Contains(t, rendered, "  - action s3:ListBucket\n")
+	assert.Contains(t, rendered, "  ~ access level of s3:PutObject: Write -> Permissions management\n")
+	assert.Contains(t, rendered, "  ~ ARN of resource type bucket: arn:${Partition}:s3:::${BucketName} -> arn:${Partition}:s3:::${BucketName}/\n")
+	assert.Contains(t, rendered, "  + condition key s3:TlsVersion\n")
+	assert.Contains(t, rendered, "  ~ type of condition key s3:prefix: String -> ArrayOfString")
+}
+
+func TestDiffServicesMergesActionRows(t *testing.T) {
+	oldServices := testServices()
+	newServices := testServices()
+	// GetObject spans two rows, only the first one has the access level
+	newServices[0].Actions = append(newServices[0].Actions[:1], newServices[0].Actions[2:]...)
+
+	assert.True(t, diffServices(oldServices, newServices).IsEmpty())
+}
+
+func TestReadServices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.json")
+	assert.NoError(t, saveCrawl(testServices(), path))
+
+	services, err := readServices(path)
+	assert.NoError(t, err)
+	assert.Equal(t, testServices(), services)
+
+	_, err = readServices(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Where `go build -tags embeddata` embeds the data from, relative to the source tree. The committed file is empty, which
+// embeds nothing.
+const EMBEDDED_DATA_PATH = "embedded/rawData.json.gz"
+const EMBEDDED_DATA_TAG = "embeddata"
+
+// encodeEmbeddedData compresses the data. The gzip header holds when it was crawled.
+func encodeEmbeddedData(services []*Service, crawledAt time.Time) ([]byte, error) {
+	jsonData, err := json.Marshal(services)
+	if err != nil {
+		return nil, err
+	}
+
+	compressed := &bytes.Buffer{}
+	w, err := gzip.NewWriterLevel(compressed, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	w.Name = RAW_DATA_PATH
+	w.ModTime = crawledAt
+	_, err = w.Write(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+func decodeEmbeddedData(data []byte) ([]*Service, time.Time, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer r.Close()
+	jsonData, err := io.ReadAll(r)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var services []*Service
+	err = json.Unmarshal(jsonData, &services)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return services, r.ModTime, nil
+}
+
+// saveEmbeddedData replaces the local data with the data embedded into the binary.
+func saveEmbeddedData(projectDir string) error {
+	services, crawledAt, err := decodeEmbeddedData(embeddedRawData)
+	if err != nil {
+		return fmt.Errorf("invalid embedded data: %w", err)
+	}
+	return saveData(services, crawledAt, projectDir)
+}
+
+func runEmbed(opts *Options, args []string) error {
+	flags := newFlagSet("embed", "embed --out <file>")
+	out := flags.String("out", "", "write the compressed data to this file, "+EMBEDDED_DATA_PATH+" of the source tree to embed it")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+	// There is no default, the source tree is wherever it was checked out
+	if len(*out) == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected --out, like --out %s in the source tree", EMBEDDED_DATA_PATH)
+	}
+	path, err := filepath.Abs(*out)
+	if err != nil {
+		return err
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+	crawledAt, err := loadDataCrawledAt(opts)
+	if err != nil {
+		return err
+	}
+	data, err := encodeEmbeddedData(services, crawledAt)
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(path, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved %d services with %d actions to %s, build with -tags %s to embed them\n", len(services), len(buildActionNames(services)), path, EMBEDDED_DATA_TAG)
+	return nil
+}
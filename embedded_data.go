@@ -0,0 +1,10 @@
+//go:build embeddata
+
+package main
+
+import _ "embed"
+
+// Generate the file with `iampolicyhelper embed --out embedded/rawData.json.gz`, it is empty otherwise
+//
+//go:embed embedded/rawData.json.gz
+var embeddedRawData []byte
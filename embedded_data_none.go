@@ -0,0 +1,6 @@
+//go:build !embeddata
+
+package main
+
+// Nothing is embedded without the embeddata build tag
+var embeddedRawData []byte
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeEmbeddedData(t *testing.T) {
+	crawledAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	data, err := encodeEmbeddedData(testServices(), crawledAt)
+	assert.NoError(t, err)
+
+	services, decodedCrawledAt, err := decodeEmbeddedData(data)
+	assert.NoError(t, err)
+	assert.Equal(t, testServices(), services)
+	assert.True(t, crawledAt.Equal(decodedCrawledAt))
+
+	_, _, err = decodeEmbeddedData([]byte("not gzip"))
+	assert.Error(t, err)
+}
+
+func TestMaybeCrawlUsesEmbeddedData(t *testing.T) {
+	crawledAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	data, err := encodeEmbeddedData(testServices(), crawledAt)
+	assert.NoError(t, err)
+	defer func(previous []byte) {
+		embeddedRawData = previous
+	}(embeddedRawData)
+	embeddedRawData = data
+
+	crawls := 0
+	offline := &Options{Crawler: func() ([]*Service, []*CrawlError, error) {
+		crawls++
+		return []*Service{}, []*CrawlError{{URL: DOCS_URL, Err: errors.New("no such host")}}, nil
+	}}
+	// Missing local data is taken from the embedded data without crawling
+	dir := t.TempDir()
+	assert.NoError(t, maybeCrawl(dir, offline))
+	assert.Equal(t, 0, crawls)
+
+	services, err := loadRawData(dir, "")
+	assert.NoError(t, err)
+	assert.Equal(t, testServices(), services)
+	savedCrawledAt, err := loadCrawledAt(dir)
+	assert.NoError(t, err)
+	assert.True(t, crawledAt.Equal(savedCrawledAt))
+
+	// Existing local data is never replaced by the embedded data
+	offline.Recrawl = true
+	assert.ErrorContains(t, maybeCrawl(dir, offline), "no services were crawled")
+
+	// A requested crawl of missing local data falls back to the embedded data
+	assert.NoError(t, maybeCrawl(filepath.Join(t.TempDir(), "recrawl"), offline))
+	assert.Equal(t, 2, crawls)
+
+	// Stale embedded data is crawled again, and kept if that fails
+	stale := &Options{Crawler: offline.Crawler, MaxAge: time.Hour, OnStale: ON_STALE_RECRAWL}
+	assert.NoError(t, maybeCrawl(filepath.Join(t.TempDir(), "stale"), stale))
+	assert.Equal(t, 3, crawls)
+
+	embeddedRawData = nil
+	offline.Recrawl = false
+	assert.ErrorContains(t, maybeCrawl(filepath.Join(t.TempDir(), "empty"), offline), "no services were crawled")
+}
+
+func TestRunEmbedRequiresOut(t *testing.T) {
+	assert.ErrorContains(t, runEmbed(&Options{}, []string{}), "expected --out")
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Matches the access level qualifiers of a query, like level:write, -level:list, or level:"permissions management". A
+// quote which isn't closed yet extends to the end of the query, so the qualifier can be typed out.
+var accessLevelQualifierRegexp = regexp.MustCompile(`(?i)(^|\s)(-?)level:("[^"]*"?|\S*)`)
+
+// AccessLevelFilter restricts actions to some access levels. Levels match case-insensitively and by prefix, so
+// "perm" stands for "Permissions management".
+type AccessLevelFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// parseAccessLevelFilter removes the access level qualifiers from the query and returns them as a filter.
+func parseAccessLevelFilter(query string) (string, *AccessLevelFilter) {
+	filter := &AccessLevelFilter{}
+	matches := accessLevelQualifierRegexp.FindAllStringSubmatch(query, -1)
+	if len(matches) == 0 {
+		return query, filter
+	}
+	for _, match := range matches {
+		level := strings.Trim(match[3], `"`)
+		if len(level) == 0 {
+			continue
+		}
+		if match[2] == "-" {
+			filter.Exclude = append(filter.Exclude, level)
+		} else {
+			filter.Include = append(filter.Include, level)
+		}
+	}
+	return strings.Join(strings.Fields(accessLevelQualifierRegexp.ReplaceAllString(query, "$1")), " "), filter
+}
+
+func (f *AccessLevelFilter) Matches(accessLevel string) bool {
+	matchesAny := func(levels []string) bool {
+		for _, it := range levels {
+			if strings.HasPrefix(strings.ToLower(accessLevel), strings.ToLower(it)) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(f.Include) > 0 && !matchesAny(f.Include) {
+		return false
+	}
+	return !matchesAny(f.Exclude)
+}
+
+// Apply returns the actions matching the filter.
+func (f *AccessLevelFilter) Apply(serviceActions []*ServiceAction) []*ServiceAction {
+	out := make([]*ServiceAction, 0, len(serviceActions))
+	for _, it := range serviceActions {
+		if f.Matches(it.Action.AccessLevel) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+func renderActionTable(serviceActions []*ServiceAction) string {
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"Action", "Access Level", "Description"})
+	table.SetColWidth(80)
+	for _, it := range serviceActions {
+		table.Append([]string{it.FullName(), it.Action.AccessLevel, it.Action.Description})
+	}
+	table.Render()
+	return tableString.String()
+}
+
+func runList(opts *Options, args []string) error {
+	flags := newFlagSet("list", "list [--service prefix] [--access-level level,...] [--output text|json]")
+	servicePrefix := flags.String("service", "", "only list the actions of the service with this prefix")
+	accessLevels := flags.String("access-level", "", "only list actions with one of these comma-separated access levels")
+	output := flags.String("output", "text", "output format, either text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	filter := &AccessLevelFilter{}
+	for _, it := range strings.Split(*accessLevels, ",") {
+		if len(strings.TrimSpace(it)) > 0 {
+			filter.Include = append(filter.Include, strings.TrimSpace(it))
+		}
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+
+	pattern := "*"
+	if len(*servicePrefix) > 0 {
+		if findServiceByQuery(*servicePrefix+":", services) == nil {
+			return fmt.Errorf("unknown service prefix %q", *servicePrefix)
+		}
+		pattern = *servicePrefix + ":*"
+	}
+	serviceActions := filter.Apply(expandWildcard(pattern, services))
+
+	if *output == "json" {
+		lookups := make([]*ActionLookup, 0, len(serviceActions))
+		for _, it := range serviceActions {
+			lookups = append(lookups, newActionLookup(it.Service, it.Action))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(lookups)
+	}
+
+	_, err = fmt.Fprint(os.Stdout, renderActionTable(serviceActions))
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccessLevelFilter(t *testing.T) {
+	query, filter := parseAccessLevelFilter(`role level:write -level:"permissions management" policy`)
+	assert.Equal(t, "role policy", query)
+	assert.Equal(t, []string{"write"}, filter.Include)
+	assert.Equal(t, []string{"permissions management"}, filter.Exclude)
+
+	// Qualifiers are recognized while they are being typed
+	query, filter = parseAccessLevelFilter(`s3:* level:"permissions man`)
+	assert.Equal(t, "s3:*", query)
+	assert.Equal(t, []string{"permissions man"}, filter.Include)
+
+	query, filter = parseAccessLevelFilter("s3:getobject level:")
+	assert.Equal(t, "s3:getobject", query)
+	assert.Equal(t, &AccessLevelFilter{}, filter)
+
+	// Only whole words are qualifiers
+	query, filter = parseAccessLevelFilter("accesslevel:write")
+	assert.Equal(t, "accesslevel:write", query)
+	assert.Equal(t, &AccessLevelFilter{}, filter)
+}
+
+func TestAccessLevelFilterMatches(t *testing.T) {
+	assert.True(t, (&AccessLevelFilter{}).Matches("Read"))
+	assert.True(t, (&AccessLevelFilter{Include: []string{"perm"}}).Matches("Permissions management"))
+	assert.False(t, (&AccessLevelFilter{Include: []string{"write"}}).Matches("Read"))
+	assert.True(t, (&AccessLevelFilter{Include: []string{"write", "read"}}).Matches("Read"))
+	assert.False(t, (&AccessLevelFilter{Exclude: []string{"READ"}}).Matches("Read"))
+	assert.False(t, (&AccessLevelFilter{Include: []string{"read"}, Exclude: []string{"read"}}).Matches("Read"))
+}
+
+func TestAccessLevelFilterApply(t *testing.T) {
+	filter := &AccessLevelFilter{Exclude: []string{"write"}}
+	names := []string{}
+	for _, it := range filter.Apply(expandWildcard("s3:*", testServices())) {
+		names = append(names, it.FullName())
+	}
+	assert.Equal(t, []string{"s3:GetObject", "s3:ListBucket"}, names)
+}
@@ -0,0 +1,52 @@
+package main
+
+// How many states the TUI remembers to go back to
+const TUI_MAX_HISTORY = 100
+
+// tuiState is what the TUI shows, so it can be restored when going back and forward.
+type tuiState struct {
+	mode        tuiMode
+	query       string
+	resultIndex int
+	// the text view's content and scroll position, which may not belong to a result, like the policy
+	text      string
+	scrollRow int
+	current   *ServiceAction
+}
+
+// history is a back/forward stack like the one of a web browser.
+type history struct {
+	back    []*tuiState
+	forward []*tuiState
+}
+
+// Push remembers the state before navigating somewhere new, which makes going forward impossible.
+func (h *history) Push(state *tuiState) {
+	h.back = append(h.back, state)
+	if len(h.back) > TUI_MAX_HISTORY {
+		h.back = h.back[len(h.back)-TUI_MAX_HISTORY:]
+	}
+	h.forward = nil
+}
+
+// Back returns the previous state, if any, and remembers the current one to go forward to again.
+func (h *history) Back(current *tuiState) *tuiState {
+	if len(h.back) == 0 {
+		return nil
+	}
+	state := h.back[len(h.back)-1]
+	h.back = h.back[:len(h.back)-1]
+	h.forward = append(h.forward, current)
+	return state
+}
+
+// Forward returns the state that was left by going back, if any, and remembers the current one to go back to again.
+func (h *history) Forward(current *tuiState) *tuiState {
+	if len(h.forward) == 0 {
+		return nil
+	}
+	state := h.forward[len(h.forward)-1]
+	h.forward = h.forward[:len(h.forward)-1]
+	h.back = append(h.back, current)
+	return state
+}
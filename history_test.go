@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	h := &history{}
+	a, b, c := &tuiState{query: "a"}, &tuiState{query: "b"}, &tuiState{query: "c"}
+
+	assert.Nil(t, h.Back(a))
+	assert.Nil(t, h.Forward(a))
+
+	h.Push(a)
+	h.Push(b)
+	assert.Equal(t, b, h.Back(c))
+	assert.Equal(t, a, h.Back(b))
+	assert.Nil(t, h.Back(a))
+	assert.Equal(t, b, h.Forward(a))
+	assert.Equal(t, c, h.Forward(b))
+	assert.Nil(t, h.Forward(c))
+
+	// Navigating somewhere new forgets the way forward
+	assert.Equal(t, b, h.Back(c))
+	h.Push(b)
+	assert.Nil(t, h.Forward(b))
+}
+
+func TestHistoryLimit(t *testing.T) {
+	h := &history{}
+	for i := 0; i < TUI_MAX_HISTORY+10; i++ {
+		h.Push(&tuiState{resultIndex: i})
+	}
+	assert.Len(t, h.back, TUI_MAX_HISTORY)
+	assert.Equal(t, 10, h.back[0].resultIndex)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// Condition keys that are available in every request, so they don't show up in the condition keys of actions or
+// resource types. The tag keys (aws:RequestTag, aws:ResourceTag, aws:TagKeys) are missing on purpose: they are only
+// supported where they are listed.
+var GLOBAL_CONDITION_KEYS = []string{
+	"aws:CalledVia",
+	"aws:CalledViaFirst",
+	"aws:CalledViaLast",
+	"aws:CurrentTime",
+	"aws:EpochTime",
+	"aws:FederatedProvider",
+	"aws:MultiFactorAuthAge",
+	"aws:MultiFactorAuthPresent",
+	"aws:PrincipalAccount",
+	"aws:PrincipalArn",
+	"aws:PrincipalIsAWSService",
+	"aws:PrincipalOrgID",
+	"aws:PrincipalOrgPaths",
+	"aws:PrincipalServiceName",
+	"aws:PrincipalServiceNamesList",
+	"aws:PrincipalTag/${TagKey}",
+	"aws:PrincipalType",
+	"aws:Referer",
+	"aws:RequestedRegion",
+	"aws:ResourceAccount",
+	"aws:ResourceOrgID",
+	"aws:ResourceOrgPaths",
+	"aws:SecureTransport",
+	"aws:SourceAccount",
+	"aws:SourceArn",
+	"aws:SourceIdentity",
+	"aws:SourceIp",
+	"aws:SourceOrgID",
+	"aws:SourceOrgPaths",
+	"aws:SourceVpc",
+	"aws:SourceVpce",
+	"aws:TokenIssueTime",
+	"aws:UserAgent",
+	"aws:userid",
+	"aws:username",
+	"aws:ViaAWSService",
+	"aws:VpcSourceIp",
+}
+
+// How many of the actions not supporting a condition key are named in a problem
+const LINT_MAX_LISTED_ACTIONS = 5
+
+// LintProblem is a problem found in a statement of a policy.
+type LintProblem struct {
+	Statement int
+	Message   string
+}
+
+func (p *LintProblem) String() string {
+	return fmt.Sprintf("Statement[%d]: %s", p.Statement, p.Message)
+}
+
+func runLint(opts *Options, args []string) error {
+	flags := newFlagSet("lint", "lint <policy.json>...")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one policy file")
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+	actionNames := buildActionNames(services)
+
+	problemCount := 0
+	for _, path := range flags.Args() {
+		policy, err := loadPolicy(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, problem := range lintPolicy(policy, services, actionNames) {
+			fmt.Fprintf(os.Stdout, "%s: %s\n", path, problem)
+			problemCount++
+		}
+	}
+
+	if problemCount > 0 {
+		return fmt.Errorf("found %d problems", problemCount)
+	}
+	return nil
+}
+
+func loadPolicy(path string) (*PolicyDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &PolicyDocument{}
+	err = json.Unmarshal(data, policy)
+	return policy, err
+}
+
+// lintPolicy checks that every action in the policy exists and that every condition key is supported by every action
+// of its statement, either by the action itself or by one of its resource types.
+func lintPolicy(policy *PolicyDocument, services []*Service, actionNames []string) []*LintProblem {
+	problems := make([]*LintProblem, 0)
+	for statementI, statement := range policy.Statement {
+		report := func(format string, a ...any) {
+			problems = append(problems, &LintProblem{Statement: statementI, Message: fmt.Sprintf(format, a...)})
+		}
+
+		// Condition keys can only be checked if we know every action they apply to
+		checkConditionKeys := len(statement.NotAction) == 0
+		serviceActions := make([]*ServiceAction, 0)
+		for _, it := range append(append([]string{}, statement.Action...), statement.NotAction...) {
+			if isWildcard(it) {
+				matches := expandWildcard(it, services)
+				if len(matches) == 0 {
+					report("action pattern %q does not match any action", it)
+				}
+				serviceActions = append(serviceActions, matches...)
+				continue
+			}
+
+			service, action, err := findAction(it, services)
+			if err != nil {
+				checkConditionKeys = false
+				suggestion := suggestActionName(it, services, actionNames)
+				if len(suggestion) > 0 {
+					report("%v, did you mean %q?", err, suggestion)
+				} else {
+					report("%v", err)
+				}
+				continue
+			}
+			serviceActions = append(serviceActions, &ServiceAction{Service: service, Action: action})
+		}
+
+		if !checkConditionKeys {
+			continue
+		}
+		conditionKeyNames := make([]string, 0)
+		for _, conditions := range statement.Condition {
+			for name := range conditions {
+				conditionKeyNames = append(conditionKeyNames, name)
+			}
+		}
+		sort.Strings(conditionKeyNames)
+		for _, name := range unique(conditionKeyNames) {
+			unsupported := make([]string, 0)
+			for _, serviceAction := range serviceActions {
+				if !isConditionKeySupported(name, serviceAction) {
+					unsupported = append(unsupported, serviceAction.FullName())
+				}
+			}
+			unsupported = unique(unsupported)
+			if len(unsupported) == 0 {
+				continue
+			}
+			if len(unsupported) == len(unique(fullNames(serviceActions))) {
+				report("condition key %q is not supported by any action of the statement", name)
+			} else if len(unsupported) > LINT_MAX_LISTED_ACTIONS {
+				report("condition key %q is not supported by %s, and %d more actions", name, strings.Join(unsupported[:LINT_MAX_LISTED_ACTIONS], ", "), len(unsupported)-LINT_MAX_LISTED_ACTIONS)
+			} else {
+				report("condition key %q is not supported by %s", name, strings.Join(unsupported, ", "))
+			}
+		}
+	}
+	return problems
+}
+
+// suggestActionName returns the properly capitalized name of the action closest to the given name, if any.
+func suggestActionName(fullActionName string, services []*Service, actionNames []string) string {
+	filter := strings.ToLower(fullActionName)
+	matches := stringWithBestMatch(filter, actionNames)
+	suggestion := ""
+	if len(matches) > 0 {
+		suggestion = matches[0].Target
+	} else {
+		// Typos that replaced a character are no subsequence of the intended name, so the fuzzy search can't find them
+		bestDistance := 4
+		for _, it := range actionNames {
+			distance := fuzzy.LevenshteinDistance(filter, it)
+			if distance < bestDistance {
+				bestDistance = distance
+				suggestion = it
+			}
+		}
+	}
+	if len(suggestion) == 0 {
+		return ""
+	}
+
+	service, action, err := findAction(suggestion, services)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s", service.Prefix, action.Name)
+}
+
+func isConditionKeySupported(name string, serviceAction *ServiceAction) bool {
+	if isGlobalConditionKey(name) {
+		return true
+	}
+	for _, it := range relevantConditionKeys(serviceAction.Service, serviceAction.Action) {
+		if conditionKeyMatches(it, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func fullNames(serviceActions []*ServiceAction) []string {
+	names := make([]string, 0, len(serviceActions))
+	for _, it := range serviceActions {
+		names = append(names, it.FullName())
+	}
+	return names
+}
+
+// conditionKeyMatches reports whether the condition key name used in a policy matches a documented condition key,
+// which may end in a placeholder like "aws:ResourceTag/${TagKey}" or "s3:ExistingObjectTag/<key>". Condition key
+// names are case-insensitive.
+func conditionKeyMatches(documented string, name string) bool {
+	documented = strings.ToLower(documented)
+	name = strings.ToLower(name)
+	placeholder := strings.IndexAny(documented, "$<")
+	if placeholder < 0 {
+		return documented == name
+	}
+	return len(name) > placeholder && strings.HasPrefix(name, documented[:placeholder])
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintPolicy(t *testing.T) {
+	services := testServices()
+	policy := &PolicyDocument{}
+	err := json.Unmarshal([]byte(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Effect": "Allow",
+				"Action": ["s3:GetObjet", "s3:GetObjact", "ec2:RunInstances", "s3:List*", "s3:Delete*"],
+				"Resource": "*"
+			},
+			{
+				"Effect": "Allow",
+				"Action": ["iam:PassRole", "s3:ListBucket"],
+				"Resource": "*",
+				"Condition": {
+					"StringEquals": {
+						"iam:PassedToService": "ec2.amazonaws.com",
+						"s3:Prefix": "home/",
+						"aws:ResourceTag/Team": "a",
+						"aws:SourceIp": "10.0.0.0/8",
+						"s3:x-amz-acl": "private"
+					}
+				}
+			},
+			{
+				"Effect": "Allow",
+				"Action": "iam:*",
+				"Resource": "*",
+				"Condition": {"StringLike": {"iam:PassedToService": "*", "s3:prefix": "home/"}}
+			},
+			{
+				"Effect": "Deny",
+				"NotAction": "iam:PassRole",
+				"Resource": "*",
+				"Condition": {"Bool": {"s3:x-amz-acl": "true"}}
+			}
+		]
+	}`), policy)
+	assert.NoError(t, err)
+
+	messages := []string{}
+	for _, it := range lintPolicy(policy, services, buildActionNames(services)) {
+		messages = append(messages, it.String())
+	}
+	assert.Equal(t, []string{
+		`Statement[0]: unknown action "s3:GetObjet", did you mean "s3:GetObject"?`,
+		`Statement[0]: unknown action "s3:GetObjact", did you mean "s3:GetObject"?`,
+		`Statement[0]: unknown service prefix "ec2"`,
+		`Statement[0]: action pattern "s3:Delete*" does not match any action`,
+		`Statement[1]: condition key "aws:ResourceTag/Team" is not supported by s3:ListBucket`,
+		`Statement[1]: condition key "iam:PassedToService" is not supported by s3:ListBucket`,
+		`Statement[1]: condition key "s3:Prefix" is not supported by iam:PassRole`,
+		`Statement[1]: condition key "s3:x-amz-acl" is not supported by any action of the statement`,
+		`Statement[2]: condition key "iam:PassedToService" is not supported by iam:AttachRolePolicy`,
+		`Statement[2]: condition key "s3:prefix" is not supported by any action of the statement`,
+	}, messages)
+}
+
+func TestLintPolicyConditionKeyOfSomeActions(t *testing.T) {
+	services := testServices()
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		services[0].Actions = append(services[0].Actions, &Action{Name: "List" + name, AccessLevel: "List"})
+	}
+	lint := func(actions string) []string {
+		policy := &PolicyDocument{}
+		err := json.Unmarshal([]byte(`{"Statement": [{"Effect": "Allow", "Action": `+actions+`, "Resource": "*", "Condition": {"StringLike": {"s3:prefix": "home/"}}}]}`), policy)
+		assert.NoError(t, err)
+		messages := []string{}
+		for _, it := range lintPolicy(policy, services, buildActionNames(services)) {
+			messages = append(messages, it.String())
+		}
+		return messages
+	}
+
+	// Only ListBucket supports s3:prefix, GetObject ignores it
+	assert.Equal(t, []string{
+		`Statement[0]: condition key "s3:prefix" is not supported by s3:GetObject`,
+	}, lint(`["s3:GetObject", "s3:ListBucket"]`))
+	assert.Equal(t, []string{
+		`Statement[0]: condition key "s3:prefix" is not supported by s3:ListA, s3:ListB, s3:ListC, s3:ListD, s3:ListE, and 2 more actions`,
+	}, lint(`"s3:List*"`))
+	assert.Empty(t, lint(`"s3:ListBucket"`))
+}
+
+func TestConditionKeyMatches(t *testing.T) {
+	assert.True(t, conditionKeyMatches("s3:prefix", "S3:Prefix"))
+	assert.True(t, conditionKeyMatches("aws:ResourceTag/${TagKey}", "aws:ResourceTag/Owner"))
+	assert.True(t, conditionKeyMatches("s3:ExistingObjectTag/<key>", "s3:ExistingObjectTag/Owner"))
+	assert.False(t, conditionKeyMatches("aws:ResourceTag/${TagKey}", "aws:ResourceTag/"))
+	assert.False(t, conditionKeyMatches("s3:prefix", "s3:prefixes"))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type ServiceSummary struct {
+	Name   string
+	Prefix string
+}
+
+// ActionLookup is the machine-readable form of everything renderBody shows for an action.
+type ActionLookup struct {
+	Service       ServiceSummary
+	Action        *Action
+	ResourceTypes []*ResourceType
+	ConditionKeys []*ConditionKey
+}
+
+func runLookup(opts *Options, args []string) error {
+	flags := newFlagSet("lookup", "lookup [--output text|json] <prefix:action>")
+	output := flags.String("output", "text", "output format, either text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one action, got %d", flags.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+
+	service, action, err := findAction(flags.Arg(0), services)
+	if err != nil {
+		return err
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newActionLookup(service, action))
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, formatForOutput(renderBody(action, service), os.Stdout))
+	return err
+}
+
+// findAction resolves a full action name like "s3:GetObject" (case-insensitive) to its service and merged action.
+func findAction(fullActionName string, services []*Service) (*Service, *Action, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(fullActionName)), ":")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return nil, nil, fmt.Errorf("invalid action %q: expected the form prefix:action", fullActionName)
+	}
+
+	service, actions := lookupByFullActionName(strings.Join(parts, ":"), services)
+	if service == nil {
+		return nil, nil, fmt.Errorf("unknown service prefix %q", parts[0])
+	}
+	action := mergeActions(actions)
+	if action == nil {
+		return nil, nil, fmt.Errorf("unknown action %q", fullActionName)
+	}
+	return service, action, nil
+}
+
+func newActionLookup(service *Service, action *Action) *ActionLookup {
+	lookup := &ActionLookup{
+		Service:       ServiceSummary{Name: service.Name, Prefix: service.Prefix},
+		Action:        action,
+		ResourceTypes: make([]*ResourceType, 0),
+		ConditionKeys: make([]*ConditionKey, 0),
+	}
+
+	seen := map[string]bool{}
+	eachResourceType(service, action, func(resourceType *ResourceType) {
+		if !seen[resourceType.Name] {
+			seen[resourceType.Name] = true
+			lookup.ResourceTypes = append(lookup.ResourceTypes, resourceType)
+		}
+	})
+	eachConditionKey(service, relevantConditionKeys(service, action), func(conditionKey *ConditionKey) {
+		lookup.ConditionKeys = append(lookup.ConditionKeys, conditionKey)
+	})
+
+	return lookup
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAction(t *testing.T) {
+	services := testServices()
+
+	service, action, err := findAction("S3:GetObject", services)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3", service.Prefix)
+	assert.Equal(t, "GetObject", action.Name)
+	assert.Equal(t, []string{"s3:ExistingObjectTag/<key>", "s3:signatureversion"}, action.ConditionKeys)
+
+	_, _, err = findAction("s3:GetObjects", services)
+	assert.EqualError(t, err, `unknown action "s3:GetObjects"`)
+
+	_, _, err = findAction("ec2:RunInstances", services)
+	assert.EqualError(t, err, `unknown service prefix "ec2"`)
+
+	_, _, err = findAction("GetObject", services)
+	assert.Error(t, err)
+}
+
+func TestTagsToANSI(t *testing.T) {
+	assert.Equal(t, "\x1b[1mAction\x1b[22m\x1b[23m\x1b[24m\x1b[25m\x1b[27m: \x1b[38;2;0;255;128ms3\x1b[38;2;255;255;255m:GetObject", tagsToANSI("[::b]Action[::-]: [#00ff80]s3[white]:GetObject"))
+	assert.Equal(t, "[]", tagsToANSI("[]"))
+}
+
+func TestNewActionLookup(t *testing.T) {
+	services := testServices()
+	service, action, err := findAction("iam:PassRole", services)
+	assert.NoError(t, err)
+
+	lookup := newActionLookup(service, action)
+	assert.Equal(t, ServiceSummary{Name: "AWS Identity and Access Management (IAM)", Prefix: "iam"}, lookup.Service)
+	assert.Equal(t, "PassRole", lookup.Action.Name)
+	assert.Equal(t, []*ResourceType{service.ResourceTypes[0]}, lookup.ResourceTypes)
+
+	conditionKeyNames := []string{}
+	for _, it := range lookup.ConditionKeys {
+		conditionKeyNames = append(conditionKeyNames, it.Name)
+	}
+	assert.Equal(t, []string{"iam:AssociatedResourceArn", "iam:PassedToService", "aws:ResourceTag/${TagKey}"}, conditionKeyNames)
+}
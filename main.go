@@ -2,8 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"math"
+	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -12,9 +17,9 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
-	"code.rocketnine.space/tslocum/cview"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly"
 	"github.com/lithammer/fuzzysearch/fuzzy"
@@ -70,64 +75,48 @@ type ServiceCells struct {
 	ConditionKeysCells [][]Cell
 }
 
-const VERSION_TAG = "v0.1.1"
+// Local data crawled by a different version is crawled again, so bump this whenever the crawled data changes
+const VERSION_TAG = "v0.2.0"
 const PROJECT_DIR = ".iampolicyhelper"
+const XDG_PROJECT_DIR = "iampolicyhelper"
 const RAW_DATA_PATH = "rawData.json"
 const VERSION_PATH = "version.txt"
+const CRAWLED_AT_PATH = "crawledAt.txt"
+const DATA_FILE_CRAWLED_AT_SUFFIX = ".crawledAt.txt"
+const CART_PATH = "cart.json"
+const TABLE_ACTIONS = "actions"
+const TABLE_RESOURCE_TYPES = "resource types"
+const TABLE_CONDITION_KEYS = "condition keys"
+const DOCS_URL = "https://docs.aws.amazon.com/service-authorization/latest/reference/reference_policies_actions-resources-contextkeys.html"
 
 func main() {
-	err := maybeCrawl(getProjectDir())
-	if err != nil {
-		panic(err)
+	err := run(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	services, err := loadRawData(getProjectDir())
-	if err != nil {
-		panic(err)
+func loadServices(opts *Options) ([]*Service, error) {
+	if len(opts.DataFile) > 0 {
+		return readServices(opts.DataFile)
 	}
 
-	actionNames := buildActionNames(services)
-
-	app := cview.NewApplication()
-	app.EnableMouse(true)
-
-	textView := cview.NewTextView()
-	textView.SetDynamicColors(true)
-	textView.SetRegions(true)
-	textView.SetMaxLines(0)
-	textView.SetScrollBarVisibility(cview.ScrollBarAuto)
-	textView.SetChangedFunc(func() {
-		app.Draw()
-	})
-
-	inputField := cview.NewInputField()
-	inputField.SetFieldWidth(0)
-	inputField.SetChangedFunc(func(text string) {
-		matches := stringWithBestMatch(text, actionNames)
-		if len(matches) > 0 {
-			service, actions := lookupByFullActionName(matches[0].Target, services)
-			action := mergeActions(actions)
-			if service != nil {
-				message := renderBody(action, service)
-				textView.SetText(message)
-				textView.ScrollToBeginning()
-			} else {
-				textView.SetText("No match")
-			}
-		} else {
-			textView.SetText("No match")
+	projectDir, err := getProjectDir(opts)
+	if err != nil {
+		return nil, err
+	}
+	// A snapshot is never stale, it shows what the documentation said back then
+	if len(opts.Snapshot) == 0 {
+		err = maybeCrawl(projectDir, opts)
+		if err != nil {
+			return nil, err
 		}
-	})
-
-	flex := cview.NewFlex()
-	flex.SetDirection(cview.FlexRow)
-	flex.AddItem(inputField, 1, 0, true)
-	flex.AddItem(textView, 0, 1, false)
-
-	app.SetRoot(flex, true)
-	if err := app.Run(); err != nil {
-		panic(err)
 	}
+
+	return loadRawData(projectDir, opts.Snapshot)
 }
 
 func eachResourceType(service *Service, action *Action, f func(*ResourceType)) {
@@ -200,11 +189,7 @@ func renderBody(action *Action, service *Service) string {
 		}
 	}
 
-	relevantConditionKeyNames := action.ConditionKeys
-	eachResourceType(service, action, func(resourceType *ResourceType) {
-		relevantConditionKeyNames = append(relevantConditionKeyNames, resourceType.ConditionKeys...)
-	})
-	relevantConditionKeyNames = unique(relevantConditionKeyNames)
+	relevantConditionKeyNames := relevantConditionKeys(service, action)
 	if len(relevantConditionKeyNames) > 0 {
 		tableString := &strings.Builder{}
 		table := tablewriter.NewWriter(tableString)
@@ -245,9 +230,23 @@ func renderBody(action *Action, service *Service) string {
 		message = re.ReplaceAllString(message, "[#4000ff]$1[white]$2")
 	}
 
+	// The links are added after the colors, because the regions contain the service prefix
+	if len(action.DependentActions) > 0 {
+		message += fmt.Sprintf("\n\n[::b]Dependent Actions[::-]: %s", renderDependentActions(action.DependentActions))
+	}
+
 	return message
 }
 
+// relevantConditionKeys returns the names of the condition keys of the action and of all of its resource types.
+func relevantConditionKeys(service *Service, action *Action) []string {
+	conditionKeyNames := append([]string{}, action.ConditionKeys...)
+	eachResourceType(service, action, func(resourceType *ResourceType) {
+		conditionKeyNames = append(conditionKeyNames, resourceType.ConditionKeys...)
+	})
+	return unique(conditionKeyNames)
+}
+
 func joinResourceTypeReferences(resourceTypeReferences []*ResourceTypeReference) string {
 	resouceTypesString := ""
 	for i, it := range resourceTypeReferences {
@@ -375,14 +374,21 @@ func stringWithBestMatch(filter string, allStrings []string) fuzzy.Ranks {
 	return matches
 }
 
-func loadRawData(projectDir string) ([]*Service, error) {
-	path := filepath.Join(projectDir, RAW_DATA_PATH)
-	f, err := os.Open(path)
+// loadRawData loads the latest crawl, or the snapshot with the ID (or ID prefix) if one is given.
+func loadRawData(projectDir string, snapshotID string) ([]*Service, error) {
+	if len(snapshotID) == 0 {
+		return readServices(filepath.Join(projectDir, RAW_DATA_PATH))
+	}
+
+	snapshot, err := loadSnapshot(projectDir, snapshotID)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	return readServices(snapshotPath(projectDir, snapshot.ID))
+}
 
+// readServices reads a data file written by saveCrawl.
+func readServices(path string) ([]*Service, error) {
 	body, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -390,23 +396,41 @@ func loadRawData(projectDir string) ([]*Service, error) {
 
 	var data []*Service
 	err = json.Unmarshal(body, &data)
-	return data, err
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
 }
 
-// TODO add cmdline flag to recrawl
-func maybeCrawl(projectDir string) error {
-	shouldCrawl := false
+// maybeCrawl crawls the IAM documentation if the local data is missing, was produced by a different version, or if
+// a recrawl was requested. Data older than opts.MaxAge is either recrawled or only warned about, depending on
+// opts.OnStale. Missing local data is taken from the data embedded into the binary right away, if there is any, and
+// also when a requested crawl fails.
+func maybeCrawl(projectDir string, opts *Options) error {
+	shouldCrawl := opts.Recrawl
 
 	// If the raw data file does not exist, we should crawl
 	rawDataPath := filepath.Join(projectDir, RAW_DATA_PATH)
 	f, err := os.Open(rawDataPath)
-	if os.IsNotExist(err) {
+	rawDataMissing := os.IsNotExist(err)
+	if rawDataMissing {
 		shouldCrawl = true
 	} else if err != nil {
 		return err
 	}
 	defer f.Close()
 
+	// The embedded data makes the first launch instant, it is refreshed like any other local data once it is stale
+	usingEmbeddedData := false
+	if rawDataMissing && !opts.Recrawl && len(embeddedRawData) > 0 {
+		err = saveEmbeddedData(projectDir)
+		if err != nil {
+			return err
+		}
+		usingEmbeddedData = true
+		shouldCrawl = false
+	}
+
 	// If the version file does not exist or has the wrong version in it, we should crawl
 	versionPath := filepath.Join(projectDir, VERSION_PATH)
 	version, err := os.ReadFile(versionPath)
@@ -417,73 +441,330 @@ func maybeCrawl(projectDir string) error {
 	}
 	shouldCrawl = shouldCrawl || strings.Trim(string(version), " \n") != VERSION_TAG
 
-	if shouldCrawl {
-		data, err := crawl()
+	// Stale data is still better than none, so it is kept if it can't be crawled again
+	onlyStale := false
+	if !shouldCrawl && opts.MaxAge > 0 {
+		crawledAt, err := loadCrawledAt(projectDir)
 		if err != nil {
 			return err
 		}
-
-		err = saveCrawl(data, rawDataPath)
-		if err != nil {
-			return err
+		age := time.Since(crawledAt)
+		if age > opts.MaxAge {
+			if opts.OnStale == ON_STALE_RECRAWL {
+				shouldCrawl = true
+				onlyStale = true
+			} else {
+				fmt.Fprintf(os.Stderr, "warning: the local IAM data is %s old, run `iampolicyhelper refresh` to update it\n", formatAge(age))
+			}
 		}
+	}
 
-		err = saveVersion(VERSION_TAG, projectDir)
-		if err != nil {
-			return err
+	if shouldCrawl {
+		if rawDataMissing && !usingEmbeddedData {
+			fmt.Fprintln(os.Stderr, "Crawling the IAM documentation for the first time, this takes a minute")
+		}
+		_, err = crawlAndSave(projectDir, opts.Crawler)
+		if err != nil && onlyStale {
+			fmt.Fprintf(os.Stderr, "warning: couldn't crawl the stale IAM data again, using the local data: %v\n", err)
+			return nil
 		}
+		if err != nil && rawDataMissing && len(embeddedRawData) > 0 {
+			fmt.Fprintf(os.Stderr, "warning: couldn't crawl the IAM data, using the data built into this binary: %v\n", err)
+			if usingEmbeddedData {
+				return nil
+			}
+			return saveEmbeddedData(projectDir)
+		}
+		return err
 	}
 
 	return nil
 }
 
-func saveCrawl(rawData []*Service, path string) error {
-	jsonData, err := json.Marshal(rawData)
+// Crawler produces the services along with the problems found in individual pages.
+type Crawler func() ([]*Service, []*CrawlError, error)
+
+func crawlAndSave(projectDir string, crawler Crawler) ([]*Service, error) {
+	data, crawlErrors, err := crawler()
+	if err != nil {
+		return nil, err
+	}
+	reportCrawlErrors(os.Stderr, crawlErrors)
+	err = checkCrawl(data, crawlErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	crawledAt := time.Now().Truncate(time.Second) // the precision of the crawl time that is saved
+	return data, saveData(data, crawledAt, projectDir)
+}
+
+// checkCrawl returns an error if the crawl must not be saved: when nothing was crawled, like without a network
+// connection, or when pages couldn't be fetched, which would silently drop their services. Problems in single rows
+// only skip those rows.
+func checkCrawl(data []*Service, crawlErrors []*CrawlError) error {
+	if len(data) == 0 {
+		return fmt.Errorf("no services were crawled")
+	}
+	failed := 0
+	for _, it := range crawlErrors {
+		if it.PageFailed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d pages couldn't be crawled, try again later", failed)
+	}
+	return nil
+}
+
+// saveData replaces the local data. The previous crawls are kept as snapshots, the raw data is always the latest one.
+func saveData(data []*Service, crawledAt time.Time, projectDir string) error {
+	err := importLegacyData(projectDir)
+	if err != nil {
+		return err
+	}
+	_, err = saveSnapshot(data, crawledAt, VERSION_TAG, projectDir)
 	if err != nil {
 		return err
 	}
 
-	err = os.MkdirAll(filepath.Dir(path), 0777)
-	if err != nil && !os.IsExist(err) {
+	err = saveCrawl(data, filepath.Join(projectDir, RAW_DATA_PATH))
+	if err != nil {
+		return err
+	}
+
+	err = saveCrawledAt(crawledAt, projectDir)
+	if err != nil {
 		return err
 	}
-	rawDataFile, err := os.Create(path)
+
+	return saveVersion(VERSION_TAG, projectDir)
+}
+
+func saveCrawl(rawData []*Service, path string) error {
+	jsonData, err := json.Marshal(rawData)
 	if err != nil {
 		return err
 	}
-	defer rawDataFile.Close()
 
-	_, err = rawDataFile.Write(jsonData)
-	return err
+	return writeFileAtomic(path, jsonData)
 }
 
 func saveVersion(versionTag string, projectDir string) error {
-	path := filepath.Join(projectDir, VERSION_PATH)
+	return writeFileAtomic(filepath.Join(projectDir, VERSION_PATH), []byte(versionTag))
+}
+
+func saveCrawledAt(crawledAt time.Time, projectDir string) error {
+	return writeCrawledAt(crawledAt, filepath.Join(projectDir, CRAWLED_AT_PATH))
+}
+
+func writeCrawledAt(crawledAt time.Time, path string) error {
+	return writeFileAtomic(path, []byte(crawledAt.UTC().Format(time.RFC3339)))
+}
+
+// loadCrawledAt returns when the local data was crawled.
+func loadCrawledAt(projectDir string) (time.Time, error) {
+	return readCrawledAt(filepath.Join(projectDir, CRAWLED_AT_PATH), filepath.Join(projectDir, RAW_DATA_PATH))
+}
+
+// readCrawledAt reads the crawl time of the data file from path. Data crawled before the timestamp was recorded falls
+// back to the modification time of the data file.
+func readCrawledAt(path string, dataPath string) (time.Time, error) {
+	crawledAt, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		info, err := os.Stat(dataPath)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return info.ModTime(), nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(crawledAt)))
+}
+
+// dataFileCrawledAtPath returns the file recording when a data file was crawled: crawledAt.txt next to a rawData.json,
+// like in a copy of the local data, and the data file's path with .crawledAt.txt appended otherwise, like crawl --out
+// writes it.
+func dataFileCrawledAtPath(dataFile string) string {
+	if filepath.Base(dataFile) == RAW_DATA_PATH {
+		return filepath.Join(filepath.Dir(dataFile), CRAWLED_AT_PATH)
+	}
+	return dataFile + DATA_FILE_CRAWLED_AT_SUFFIX
+}
+
+// loadDataCrawledAt returns when the data used by the commands was crawled.
+func loadDataCrawledAt(opts *Options) (time.Time, error) {
+	if len(opts.DataFile) > 0 {
+		return readCrawledAt(dataFileCrawledAtPath(opts.DataFile), opts.DataFile)
+	}
+
+	projectDir, err := getProjectDir(opts)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(opts.Snapshot) > 0 {
+		snapshot, err := loadSnapshot(projectDir, opts.Snapshot)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return snapshot.CrawledAt, nil
+	}
+	return loadCrawledAt(projectDir)
+}
+
+func formatAge(age time.Duration) string {
+	days := int(age.Hours() / 24)
+	hours := int(age.Hours())
+	switch {
+	case days == 1:
+		return "1 day"
+	case days > 1:
+		return fmt.Sprintf("%d days", days)
+	case hours == 1:
+		return "1 hour"
+	case hours > 1:
+		return fmt.Sprintf("%d hours", hours)
+	default:
+		return "less than an hour"
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and then renames it over path, so readers never see
+// a partially written file.
+func writeFileAtomic(path string, data []byte) error {
 	err := os.MkdirAll(filepath.Dir(path), 0777)
 	if err != nil && !os.IsExist(err) {
 		return err
 	}
-	f, err := os.Create(path)
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	defer os.Remove(f.Name()) // no-op once the rename succeeded
 
-	_, err = f.Write([]byte(versionTag))
-	return err
+	err = f.Chmod(0644)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	return os.Rename(f.Name(), path)
 }
 
-func crawl() ([]*Service, error) {
+func crawl() ([]*Service, []*CrawlError, error) {
+	return crawlDocs(DOCS_URL, nil)
+}
+
+// crawlFromDir crawls a local copy of the documentation. The directory must contain the saved index page and service
+// pages under their original file names.
+func crawlFromDir(dir string) ([]*Service, []*CrawlError, error) {
+	indexPath := filepath.Join(dir, path.Base(DOCS_URL))
+	_, err := os.Stat(indexPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("missing index page: %w", err)
+	}
+
+	return crawlDocs(DOCS_URL, &localDocsTransport{files: http.NewFileTransport(http.Dir(dir))})
+}
+
+// localDocsTransport answers every request with the file of the same name from a directory of saved pages, so the
+// crawler sees the same URLs it would see online.
+type localDocsTransport struct {
+	files http.RoundTripper
+}
+
+func (t *localDocsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	local := req.Clone(req.Context())
+	local.URL = &url.URL{Path: "/" + path.Base(req.URL.Path)}
+	res, err := t.files.RoundTrip(local)
+	if err != nil {
+		return nil, err
+	}
+	res.Request = req // the crawler keys its state by the URL of the response's request
+	return res, nil
+}
+
+// CrawlError describes a page that could not be fetched or a table row of a page that could not be parsed. The rest
+// of the page is still used.
+type CrawlError struct {
+	URL   string
+	Table string
+	Row   int
+	Err   error
+}
+
+func (e *CrawlError) Error() string {
+	if len(e.Table) == 0 {
+		return fmt.Sprintf("%s: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("%s: %s table, row %d: %v", e.URL, e.Table, e.Row+1, e.Err)
+}
+
+// PageFailed returns whether the whole page couldn't be crawled rather than a row of one of its tables.
+func (e *CrawlError) PageFailed() bool {
+	return len(e.Table) == 0
+}
+
+func (e *CrawlError) Unwrap() error {
+	return e.Err
+}
+
+func reportCrawlErrors(w io.Writer, crawlErrors []*CrawlError) {
+	if len(crawlErrors) == 0 {
+		return
+	}
+
+	urls := map[string]bool{}
+	for _, it := range crawlErrors {
+		urls[it.URL] = true
+	}
+	fmt.Fprintf(w, "warning: %d problems in %d pages, the affected rows were skipped:\n", len(crawlErrors), len(urls))
+	for _, it := range crawlErrors {
+		fmt.Fprintf(w, "  %s\n", it)
+	}
+}
+
+// crawlDocs crawls the documentation starting at the index page at startURL. A nil transport uses the network.
+// Problems with individual pages are collected and returned alongside the services that could be crawled.
+func crawlDocs(startURL string, transport http.RoundTripper) ([]*Service, []*CrawlError, error) {
 	c := colly.NewCollector(
 		colly.MaxDepth(2),
 		colly.Async(true),
 	)
+	if transport != nil {
+		c.WithTransport(transport)
+	}
 
 	c.Limit(&colly.LimitRule{Parallelism: 2})
 
 	// URL : Service
 	serviceDataMutex := sync.Mutex{}
 	serviceData := make(map[string]*ServiceCells)
+	crawlErrors := make([]*CrawlError, 0)
+
+	// Must be called with serviceDataMutex held. Requests that got redirected show up under a URL that was never
+	// requested.
+	getServiceCells := func(url string) *ServiceCells {
+		serviceCells, ok := serviceData[url]
+		if !ok {
+			serviceCells = &ServiceCells{}
+			serviceData[url] = serviceCells
+		}
+		return serviceCells
+	}
 
 	c.OnRequest(func(r *colly.Request) {
 		url := r.AbsoluteURL(r.URL.String())
@@ -492,6 +773,13 @@ func crawl() ([]*Service, error) {
 		serviceData[url] = &ServiceCells{}
 	})
 
+	c.OnError(func(r *colly.Response, err error) {
+		url := r.Request.AbsoluteURL(r.Request.URL.String())
+		serviceDataMutex.Lock()
+		defer serviceDataMutex.Unlock()
+		crawlErrors = append(crawlErrors, &CrawlError{URL: url, Err: err})
+	})
+
 	c.OnHTML(".highlights ul li a[href]", func(e *colly.HTMLElement) {
 		url := e.Request.AbsoluteURL(e.Attr("href"))
 		e.Request.Visit(url)
@@ -502,7 +790,7 @@ func crawl() ([]*Service, error) {
 			url := h.Request.AbsoluteURL(h.Request.URL.String())
 			serviceDataMutex.Lock()
 			defer serviceDataMutex.Unlock()
-			serviceCells := serviceData[url]
+			serviceCells := getServiceCells(url)
 			serviceCells.Name = strings.Trim(strings.Split(h.Text, "(")[0], " ")
 			serviceCells.Prefix = h.ChildText("code")
 		}
@@ -512,36 +800,42 @@ func crawl() ([]*Service, error) {
 		url := e.Request.AbsoluteURL(e.Request.URL.String())
 		serviceDataMutex.Lock()
 		defer serviceDataMutex.Unlock()
-		serviceCells := serviceData[url]
+		serviceCells := getServiceCells(url)
+
+		var tableName string
+		var tableCells *[][]Cell
 		headerText := strings.ToLower(e.ChildText("table tr th"))
 		if strings.HasPrefix(headerText, "actions") {
-			e.ForEach("table tbody tr", func(i int, h *colly.HTMLElement) {
-				rows := crawlTableRows(h)
-				serviceCells.ActionsCells = append(serviceCells.ActionsCells, rows)
-			})
+			tableName, tableCells = TABLE_ACTIONS, &serviceCells.ActionsCells
 		} else if strings.HasPrefix(headerText, "resource types") {
-			e.ForEach("table tbody tr", func(i int, h *colly.HTMLElement) {
-				rows := crawlTableRows(h)
-				serviceCells.ResourcesCells = append(serviceCells.ResourcesCells, rows)
-			})
+			tableName, tableCells = TABLE_RESOURCE_TYPES, &serviceCells.ResourcesCells
 		} else if strings.HasPrefix(headerText, "condition keys") {
-			e.ForEach("table tbody tr", func(i int, h *colly.HTMLElement) {
-				rows := crawlTableRows(h)
-				serviceCells.ConditionKeysCells = append(serviceCells.ConditionKeysCells, rows)
-			})
+			tableName, tableCells = TABLE_CONDITION_KEYS, &serviceCells.ConditionKeysCells
+		} else {
+			return
 		}
+
+		e.ForEach("table tbody tr", func(i int, h *colly.HTMLElement) {
+			rows, err := crawlTableRows(h)
+			if err != nil {
+				crawlErrors = append(crawlErrors, &CrawlError{URL: url, Table: tableName, Row: i, Err: err})
+			} else if len(rows) > 0 {
+				*tableCells = append(*tableCells, rows)
+			}
+		})
 	})
 
-	err := c.Visit("https://docs.aws.amazon.com/service-authorization/latest/reference/reference_policies_actions-resources-contextkeys.html")
+	err := c.Visit(startURL)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	c.Wait()
 
 	services := make([]*Service, 0)
 	for url, serviceCells := range serviceData {
-		if len(removeSpace(url)) == 0 {
+		if len(removeSpace(url)) == 0 || len(serviceCells.Prefix) == 0 {
+			// pages without a service prefix, like the index page, don't describe a service
 			continue
 		}
 
@@ -549,9 +843,15 @@ func crawl() ([]*Service, error) {
 		resourcesTable := htmlTableTo2D(serviceCells.ResourcesCells)
 		conditionKeysTable := htmlTableTo2D(serviceCells.ConditionKeysCells)
 
-		actions := actionsFromTable(actionTable)
-		resources := resourcesFromTable(resourcesTable)
-		conditionKeys := conditionKeysFromTable(conditionKeysTable)
+		actions, actionErrors := actionsFromTable(actionTable)
+		resources, resourceErrors := resourcesFromTable(resourcesTable)
+		conditionKeys, conditionKeyErrors := conditionKeysFromTable(conditionKeysTable)
+		for _, tableErrors := range [][]*CrawlError{actionErrors, resourceErrors, conditionKeyErrors} {
+			for _, it := range tableErrors {
+				it.URL = url
+				crawlErrors = append(crawlErrors, it)
+			}
+		}
 
 		service := &Service{
 			URL:           url,
@@ -567,38 +867,49 @@ func crawl() ([]*Service, error) {
 	sort.Slice(services, func(i, j int) bool {
 		return services[i].Name < services[j].Name
 	})
+	sort.SliceStable(crawlErrors, func(i, j int) bool {
+		return crawlErrors[i].URL < crawlErrors[j].URL
+	})
 
-	return services, nil
+	return services, crawlErrors, nil
 }
 
-func crawlTableRows(h *colly.HTMLElement) []Cell {
+// crawlTableRows returns the cells of a table row. Header rows have no cells.
+func crawlTableRows(h *colly.HTMLElement) ([]Cell, error) {
 	rows := make([]Cell, 0)
 
-	h.DOM.ChildrenFiltered("td").Each(func(i int, s *goquery.Selection) {
-		rowspanStr, ok := s.Attr("rowspan")
-		rowspan := 1
-		if ok {
-			var err error
-			rowspan, err = strconv.Atoi(rowspanStr)
-			if err != nil {
-				panic(err)
-			}
+	var err error
+	h.DOM.ChildrenFiltered("td").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var rowspan, colspan int
+		rowspan, err = spanAttr(s, "rowspan")
+		if err != nil {
+			return false
 		}
-
-		colspanStr, ok := s.Attr("colspan")
-		colspan := 1
-		if ok {
-			var err error
-			colspan, err = strconv.Atoi(colspanStr)
-			if err != nil {
-				panic(err)
-			}
+		colspan, err = spanAttr(s, "colspan")
+		if err != nil {
+			return false
 		}
 
 		rows = append(rows, Cell{Rowspan: rowspan, Colspan: colspan, Text: s.Text()})
+		return true
 	})
 
-	return rows
+	return rows, err
+}
+
+func spanAttr(s *goquery.Selection, name string) (int, error) {
+	spanStr, ok := s.Attr(name)
+	if !ok {
+		return 1, nil
+	}
+	span, err := strconv.Atoi(strings.TrimSpace(spanStr))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if span < 0 {
+		return 0, fmt.Errorf("invalid %s: %d is negative", name, span)
+	}
+	return span, nil
 }
 
 // Removes leading and trailing whitespace.
@@ -614,9 +925,28 @@ func cleanupHTMLStringList(ss []string) []string {
 	return out
 }
 
-func actionsFromTable(table [][]string) []*Action {
-	actions := make([]*Action, len(table))
+func checkColumns(tableName string, table [][]string, columns int) []*CrawlError {
+	crawlErrors := make([]*CrawlError, 0)
 	for rowI, row := range table {
+		if len(row) < columns {
+			crawlErrors = append(crawlErrors, &CrawlError{
+				Table: tableName,
+				Row:   rowI,
+				Err:   fmt.Errorf("expected %d columns, got %d", columns, len(row)),
+			})
+		}
+	}
+	return crawlErrors
+}
+
+// actionsFromTable converts the dense actions table to actions. Rows that are too short are skipped and reported.
+func actionsFromTable(table [][]string) ([]*Action, []*CrawlError) {
+	crawlErrors := checkColumns(TABLE_ACTIONS, table, 6)
+	actions := make([]*Action, 0, len(table))
+	for _, row := range table {
+		if len(row) < 6 {
+			continue
+		}
 		resourceTypeStrings := cleanupHTMLStringList(strings.Split(row[3], "\n"))
 		resourceTypes := make([]*ResourceTypeReference, 0)
 		for _, it := range resourceTypeStrings {
@@ -632,42 +962,56 @@ func actionsFromTable(table [][]string) []*Action {
 			})
 		}
 		action := &Action{
-			Name:                   strings.Trim(row[0], " \n\t"),
+			Name:                   actionNameFromCell(row[0]),
 			Description:            strings.Trim(row[1], " \n\t"),
 			AccessLevel:            strings.Trim(row[2], " \n\t"),
 			ResourceTypeReferences: resourceTypes,
 			ConditionKeys:          cleanupHTMLStringList(strings.Split(row[4], "\n")),
 			DependentActions:       cleanupHTMLStringList(strings.Split(row[5], "\n")),
 		}
-		actions[rowI] = action
+		actions = append(actions, action)
 	}
-	return actions
+	return actions, crawlErrors
 }
 
-func resourcesFromTable(table [][]string) []*ResourceType {
-	resources := make([]*ResourceType, len(table))
-	for rowI, row := range table {
+// actionNameFromCell removes annotations like "[permission only]" that follow the action name.
+func actionNameFromCell(text string) string {
+	name, _, _ := strings.Cut(strings.Trim(text, " \n\t"), "[")
+	return strings.Trim(name, " \n\t")
+}
+
+func resourcesFromTable(table [][]string) ([]*ResourceType, []*CrawlError) {
+	crawlErrors := checkColumns(TABLE_RESOURCE_TYPES, table, 3)
+	resources := make([]*ResourceType, 0, len(table))
+	for _, row := range table {
+		if len(row) < 3 {
+			continue
+		}
 		resource := &ResourceType{
 			Name:          strings.Trim(row[0], " \n\t"),
 			ARN:           strings.Trim(row[1], " \n\t"),
 			ConditionKeys: cleanupHTMLStringList(strings.Split(row[2], "\n")),
 		}
-		resources[rowI] = resource
+		resources = append(resources, resource)
 	}
-	return resources
+	return resources, crawlErrors
 }
 
-func conditionKeysFromTable(table [][]string) []*ConditionKey {
-	conditionKeys := make([]*ConditionKey, len(table))
-	for rowI, row := range table {
+func conditionKeysFromTable(table [][]string) ([]*ConditionKey, []*CrawlError) {
+	crawlErrors := checkColumns(TABLE_CONDITION_KEYS, table, 3)
+	conditionKeys := make([]*ConditionKey, 0, len(table))
+	for _, row := range table {
+		if len(row) < 3 {
+			continue
+		}
 		conditionKey := &ConditionKey{
 			Name:        strings.Trim(row[0], " \n\t"),
 			Description: strings.Trim(row[1], " \n\t"),
 			Type:        strings.Trim(row[2], " \n\t"),
 		}
-		conditionKeys[rowI] = conditionKey
+		conditionKeys = append(conditionKeys, conditionKey)
 	}
-	return conditionKeys
+	return conditionKeys, crawlErrors
 }
 
 // Converts a sparse HTML table represented by Cells to a dense table of strings
@@ -801,10 +1145,33 @@ func removeSpace(s string) string {
 	return string(rr)
 }
 
-func getProjectDir() string {
-	homedir, err := os.UserHomeDir()
-	if err != nil {
-		panic(err)
+// getProjectDir returns the directory the local data is kept in: the one given by --data-dir or IAMPOLICYHELPER_HOME,
+// ~/.iampolicyhelper if it exists, and the XDG cache directory otherwise.
+func getProjectDir(opts *Options) (string, error) {
+	if len(opts.DataDir) > 0 {
+		return opts.DataDir, nil
+	}
+	return getUserDir("XDG_CACHE_HOME", ".cache")
+}
+
+// getUserDir returns ~/.iampolicyhelper if it exists, which was used for everything before the XDG base directories,
+// and otherwise the directory of this program in the XDG base directory named by env, which defaults to fallback in
+// the home directory.
+func getUserDir(env string, fallback string) (string, error) {
+	homedir, homeErr := os.UserHomeDir()
+	if homeErr == nil {
+		_, err := os.Stat(filepath.Join(homedir, PROJECT_DIR))
+		if err == nil {
+			return filepath.Join(homedir, PROJECT_DIR), nil
+		}
+	}
+	// The XDG spec requires the path to be absolute
+	base := os.Getenv(env)
+	if filepath.IsAbs(base) {
+		return filepath.Join(base, XDG_PROJECT_DIR), nil
+	}
+	if homeErr != nil {
+		return "", fmt.Errorf("no directory for the local data, pass --data-dir or set IAMPOLICYHELPER_HOME: %w", homeErr)
 	}
-	return path.Join(homedir, PROJECT_DIR)
+	return filepath.Join(homedir, fallback, XDG_PROJECT_DIR), nil
 }
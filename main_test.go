@@ -1,7 +1,17 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -92,3 +102,311 @@ func TestHTMLTableTo2D_C(t *testing.T) {
 
 	assert.Equal(t, expected, table)
 }
+
+func testServices() []*Service {
+	return []*Service{
+		{
+			URL:    "https://docs.aws.amazon.com/service-authorization/latest/reference/list_amazons3.html",
+			Name:   "Amazon S3",
+			Prefix: "s3",
+			Actions: []*Action{
+				{
+					Name:                   "GetObject",
+					Description:            "Grants permission to retrieve objects from Amazon S3",
+					AccessLevel:            "Read",
+					ResourceTypeReferences: []*ResourceTypeReference{{Name: "object", Required: true}},
+					ConditionKeys:          []string{"s3:ExistingObjectTag/<key>"},
+				},
+				{
+					Name:                   "GetObject",
+					ResourceTypeReferences: []*ResourceTypeReference{},
+					ConditionKeys:          []string{"s3:signatureversion"},
+				},
+				{
+					Name:                   "PutObject",
+					Description:            "Grants permission to add an object to a bucket",
+					AccessLevel:            "Write",
+					ResourceTypeReferences: []*ResourceTypeReference{{Name: "object", Required: true}},
+					ConditionKeys:          []string{"s3:x-amz-acl"},
+				},
+				{
+					Name:                   "ListBucket",
+					Description:            "Grants permission to list some or all of the objects in an Amazon S3 bucket",
+					AccessLevel:            "List",
+					ResourceTypeReferences: []*ResourceTypeReference{{Name: "bucket", Required: true}},
+					ConditionKeys:          []string{"s3:prefix"},
+				},
+			},
+			ResourceTypes: []*ResourceType{
+				{Name: "bucket", ARN: "arn:${Partition}:s3:::${BucketName}"},
+				{Name: "object", ARN: "arn:${Partition}:s3:::${BucketName}/${ObjectName}"},
+			},
+			ConditionKeys: []*ConditionKey{
+				{Name: "s3:ExistingObjectTag/<key>", Description: "Filters access by an existing object tag key and value", Type: "String"},
+				{Name: "s3:prefix", Description: "Filters access by key name prefix", Type: "String"},
+				{Name: "s3:signatureversion", Description: "Filters access by the version of AWS Signature used on the request", Type: "String"},
+				{Name: "s3:x-amz-acl", Description: "Filters access by canned ACL in the request's x-amz-acl header", Type: "String"},
+			},
+		},
+		{
+			URL:    "https://docs.aws.amazon.com/service-authorization/latest/reference/list_awsidentityandaccessmanagementiam.html",
+			Name:   "AWS Identity and Access Management (IAM)",
+			Prefix: "iam",
+			Actions: []*Action{
+				{
+					Name:                   "AttachRolePolicy",
+					Description:            "Grants permission to attach a managed policy to the specified IAM role",
+					AccessLevel:            "Permissions management",
+					ResourceTypeReferences: []*ResourceTypeReference{{Name: "role", Required: true}},
+					ConditionKeys:          []string{"iam:PolicyARN", "iam:PermissionsBoundary"},
+				},
+				{
+					Name:                   "PassRole",
+					Description:            "Grants permission to pass a role to a service",
+					AccessLevel:            "Write",
+					ResourceTypeReferences: []*ResourceTypeReference{{Name: "role", Required: true}},
+					ConditionKeys:          []string{"iam:AssociatedResourceArn", "iam:PassedToService"},
+				},
+			},
+			ResourceTypes: []*ResourceType{
+				{Name: "role", ARN: "arn:${Partition}:iam::${Account}:role/${RoleNameWithPath}", ConditionKeys: []string{"aws:ResourceTag/${TagKey}"}},
+			},
+			ConditionKeys: []*ConditionKey{
+				{Name: "aws:ResourceTag/${TagKey}", Description: "Filters access by the tags attached to the resource", Type: "String"},
+				{Name: "iam:AssociatedResourceArn", Description: "Filters access by the resource that the role will be used on behalf of", Type: "ARN"},
+				{Name: "iam:PassedToService", Description: "Filters access by the AWS service to which this role is passed", Type: "String"},
+				{Name: "iam:PermissionsBoundary", Description: "Filters access if the specified policy is set as the permissions boundary", Type: "String"},
+				{Name: "iam:PolicyARN", Description: "Filters access by the ARN of an IAM policy", Type: "ARN"},
+			},
+		},
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", RAW_DATA_PATH)
+
+	assert.NoError(t, writeFileAtomic(path, []byte("first")))
+	assert.NoError(t, writeFileAtomic(path, []byte("second")))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	// The temporary files must not be left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLoadCrawledAt(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, saveCrawl([]*Service{}, filepath.Join(dir, RAW_DATA_PATH)))
+
+	// Falls back to the modification time of the raw data when no timestamp was recorded
+	info, err := os.Stat(filepath.Join(dir, RAW_DATA_PATH))
+	assert.NoError(t, err)
+	crawledAt, err := loadCrawledAt(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, info.ModTime(), crawledAt)
+
+	expected := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	assert.NoError(t, saveCrawledAt(expected, dir))
+	crawledAt, err = loadCrawledAt(dir)
+	assert.NoError(t, err)
+	assert.True(t, expected.Equal(crawledAt))
+}
+
+func TestMaybeCrawlKeepsStaleDataIfCrawlFails(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, saveData(testServices(), time.Now().Add(-48*time.Hour), dir))
+
+	offline := func() ([]*Service, []*CrawlError, error) {
+		return []*Service{}, []*CrawlError{{URL: DOCS_URL, Err: errors.New("no such host")}}, nil
+	}
+	opts := &Options{Crawler: offline, MaxAge: 24 * time.Hour, OnStale: ON_STALE_RECRAWL}
+	assert.NoError(t, maybeCrawl(dir, opts))
+	services, err := loadRawData(dir, "")
+	assert.NoError(t, err)
+	assert.Equal(t, testServices(), services)
+
+	// An explicitly requested crawl still fails
+	opts.Recrawl = true
+	assert.ErrorContains(t, maybeCrawl(dir, opts), "no services were crawled")
+}
+
+func TestCrawlAndSaveRejectsFailedPages(t *testing.T) {
+	dir := t.TempDir()
+	_, err := crawlAndSave(dir, func() ([]*Service, []*CrawlError, error) {
+		return testServices()[:1], []*CrawlError{{URL: "list_awsidentityandaccessmanagementiam.html", Err: errors.New("connection reset")}}, nil
+	})
+	assert.ErrorContains(t, err, "1 pages couldn't be crawled")
+	_, err = os.Stat(filepath.Join(dir, RAW_DATA_PATH))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Only the rows with problems are skipped
+	_, err = crawlAndSave(dir, func() ([]*Service, []*CrawlError, error) {
+		return testServices(), []*CrawlError{{URL: "list_amazons3.html", Table: TABLE_ACTIONS, Row: 3, Err: errors.New("expected 6 columns, got 2")}}, nil
+	})
+	assert.NoError(t, err)
+	services, err := loadRawData(dir, "")
+	assert.NoError(t, err)
+	assert.Equal(t, testServices(), services)
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "less than an hour", formatAge(5*time.Minute))
+	assert.Equal(t, "1 hour", formatAge(90*time.Minute))
+	assert.Equal(t, "23 hours", formatAge(23*time.Hour))
+	assert.Equal(t, "1 day", formatAge(25*time.Hour))
+	assert.Equal(t, "30 days", formatAge(30*24*time.Hour))
+}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestCrawl crawls the recorded documentation pages in testdata/docs and compares the result against
+// testdata/golden.json. Run `go test -run TestCrawl -update` to regenerate the golden file after changing the crawler.
+func TestCrawl(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir(filepath.Join("testdata", "docs"))))
+	defer server.Close()
+
+	services, crawlErrors, err := crawlDocs(server.URL+"/"+path.Base(DOCS_URL), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, crawlErrors)
+	for _, service := range services {
+		// the server listens on a random port
+		service.URL = strings.TrimPrefix(service.URL, server.URL)
+	}
+
+	actual, err := json.MarshalIndent(services, "", "  ")
+	assert.NoError(t, err)
+
+	goldenPath := filepath.Join("testdata", "golden.json")
+	if *update {
+		assert.NoError(t, os.WriteFile(goldenPath, actual, 0644))
+	}
+	expected, err := os.ReadFile(goldenPath)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+}
+
+func TestCrawlFromDir(t *testing.T) {
+	services, crawlErrors, err := crawlFromDir(filepath.Join("testdata", "docs"))
+	assert.NoError(t, err)
+	assert.Empty(t, crawlErrors)
+	assert.Len(t, services, 2)
+	assert.Equal(t, "https://docs.aws.amazon.com/service-authorization/latest/reference/list_amazons3.html", services[1].URL)
+
+	_, _, err = crawlFromDir(t.TempDir())
+	assert.ErrorContains(t, err, "missing index page")
+}
+
+func TestCrawlMalformedPages(t *testing.T) {
+	dir := t.TempDir()
+	index := `<html><body><div class="highlights"><ul>
+<li><a href="./list_broken.html">Broken</a></li>
+<li><a href="./list_missing.html">Missing</a></li>
+</ul></div></body></html>`
+	broken := `<html><body><div id="main-content">
+<p>Broken Service (service prefix: <code>broken</code>) provides the following resources.</p>
+<div class="table-container"><table>
+<thead><tr><th>Actions</th><th>Description</th><th>Access level</th><th>Resource types (*required)</th><th>Condition keys</th><th>Dependent actions</th></tr></thead>
+<tbody>
+<tr><td rowspan="x">BadSpan</td><td>d</td><td>Read</td><td></td><td></td><td></td></tr>
+<tr><td>Good</td><td>Grants permission to do good things</td><td>Write</td><td></td><td></td><td></td></tr>
+</tbody>
+</table></div>
+<div class="table-container"><table>
+<thead><tr><th>Resource types</th><th>ARN</th><th>Condition keys</th></tr></thead>
+<tbody>
+<tr><td>thing</td><td>arn:${Partition}:broken:::${ThingName}</td></tr>
+</tbody>
+</table></div>
+</div></body></html>`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, path.Base(DOCS_URL)), []byte(index), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "list_broken.html"), []byte(broken), 0644))
+
+	services, crawlErrors, err := crawlFromDir(dir)
+	assert.NoError(t, err)
+
+	assert.Len(t, services, 1)
+	assert.Len(t, services[0].Actions, 1)
+	assert.Equal(t, "Good", services[0].Actions[0].Name)
+	assert.Empty(t, services[0].ResourceTypes)
+
+	messages := []string{}
+	for _, it := range crawlErrors {
+		messages = append(messages, it.Error())
+	}
+	baseURL := strings.TrimSuffix(DOCS_URL, path.Base(DOCS_URL))
+	assert.ElementsMatch(t, []string{
+		baseURL + `list_broken.html: actions table, row 1: invalid rowspan: strconv.Atoi: parsing "x": invalid syntax`,
+		baseURL + "list_broken.html: resource types table, row 1: expected 3 columns, got 2",
+		baseURL + "list_missing.html: Not Found",
+	}, messages)
+}
+
+func TestGetProjectDir(t *testing.T) {
+	home := t.TempDir()
+	cache := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	dir, err := getProjectDir(&Options{DataDir: "/data"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/data", dir)
+
+	// The XDG spec's default applies without XDG_CACHE_HOME
+	dir, err = getProjectDir(&Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".cache", XDG_PROJECT_DIR), dir)
+
+	t.Setenv("XDG_CACHE_HOME", cache)
+	dir, err = getProjectDir(&Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(cache, XDG_PROJECT_DIR), dir)
+
+	// The XDG cache directory is only used when there is no data in the home directory yet
+	assert.NoError(t, os.Mkdir(filepath.Join(home, PROJECT_DIR), 0755))
+	dir, err = getProjectDir(&Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, PROJECT_DIR), dir)
+
+	// Without a home directory, there's an error instead of a panic
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "relative")
+	_, err = getProjectDir(&Options{})
+	assert.ErrorContains(t, err, "pass --data-dir or set IAMPOLICYHELPER_HOME")
+}
+
+func TestLoadServicesFromDataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baked.json")
+	assert.NoError(t, saveCrawl(testServices(), path))
+	// The data file must be used as is, even without a data directory
+	opts := &Options{DataFile: path, DataDir: filepath.Join(t.TempDir(), "missing"), Recrawl: true}
+
+	services, err := loadServices(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, testServices(), services)
+
+	// Without a recorded crawl time, the data file was crawled when it was written
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	crawledAt, err := loadDataCrawledAt(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, info.ModTime(), crawledAt)
+
+	recorded := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	assert.NoError(t, writeCrawledAt(recorded, path+DATA_FILE_CRAWLED_AT_SUFFIX))
+	crawledAt, err = loadDataCrawledAt(opts)
+	assert.NoError(t, err)
+	assert.True(t, recorded.Equal(crawledAt))
+
+	// A copy of the local data has its crawl time next to it
+	dir := t.TempDir()
+	assert.NoError(t, saveCrawl(testServices(), filepath.Join(dir, RAW_DATA_PATH)))
+	assert.NoError(t, saveCrawledAt(recorded, dir))
+	crawledAt, err = loadDataCrawledAt(&Options{DataFile: filepath.Join(dir, RAW_DATA_PATH)})
+	assert.NoError(t, err)
+	assert.True(t, recorded.Equal(crawledAt))
+}
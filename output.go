@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Matches cview color tags of the form [foreground:background:attributes], where every part is optional.
+var colorTagPattern = regexp.MustCompile(`\[([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([lbdiru]+|\-)?)?)?\]`)
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatForOutput prepares text containing cview tags for printing to f. Terminals get the colors translated to ANSI
+// escape sequences, everything else gets plain text.
+func formatForOutput(text string, f *os.File) string {
+	if isTerminal(f) {
+		return tagsToANSI(string(cview.StripTags([]byte(text), false, true))) + "\x1b[0m"
+	}
+	return string(cview.StripTags([]byte(text), true, true))
+}
+
+func tagsToANSI(text string) string {
+	return colorTagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		if len(tag) <= 2 {
+			// "[]" is not a color tag
+			return tag
+		}
+
+		parts := strings.Split(tag[1:len(tag)-1], ":")
+		codes := []string{}
+		if len(parts) > 0 && len(parts[0]) > 0 {
+			codes = append(codes, ansiColorCode(parts[0], 38, 39))
+		}
+		if len(parts) > 1 && len(parts[1]) > 0 {
+			codes = append(codes, ansiColorCode(parts[1], 48, 49))
+		}
+		if len(parts) > 2 && len(parts[2]) > 0 {
+			codes = append(codes, ansiAttributeCodes(parts[2])...)
+		}
+
+		out := ""
+		for _, code := range codes {
+			if len(code) > 0 {
+				out += fmt.Sprintf("\x1b[%sm", code)
+			}
+		}
+		return out
+	})
+}
+
+func ansiColorCode(name string, setCode int, resetCode int) string {
+	if name == "-" {
+		return fmt.Sprint(resetCode)
+	}
+	r, g, b := tcell.GetColor(name).RGB()
+	if r < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d;2;%d;%d;%d", setCode, r, g, b)
+}
+
+func ansiAttributeCodes(attributes string) []string {
+	if attributes == "-" {
+		// Reset bold/dim, italic, underline, blink and reverse without touching the colors
+		return []string{"22", "23", "24", "25", "27"}
+	}
+
+	codes := []string{}
+	for _, attribute := range attributes {
+		switch attribute {
+		case 'b':
+			codes = append(codes, "1")
+		case 'd':
+			codes = append(codes, "2")
+		case 'i':
+			codes = append(codes, "3")
+		case 'u':
+			codes = append(codes, "4")
+		case 'l':
+			codes = append(codes, "5")
+		case 'r':
+			codes = append(codes, "7")
+		}
+	}
+	return codes
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const POLICY_VERSION = "2012-10-17"
+
+type PolicyDocument struct {
+	Version   string
+	Statement PolicyStatements
+}
+
+type PolicyStatement struct {
+	Sid         string `json:",omitempty"`
+	Effect      string
+	Action      StringList                            `json:",omitempty"`
+	NotAction   StringList                            `json:",omitempty"`
+	Resource    StringList                            `json:",omitempty"`
+	NotResource StringList                            `json:",omitempty"`
+	Condition   map[string]map[string]json.RawMessage `json:",omitempty"`
+}
+
+// PolicyStatements is a list of statements which also accepts a single statement object when unmarshalled, like IAM
+// does.
+type PolicyStatements []*PolicyStatement
+
+func (s *PolicyStatements) UnmarshalJSON(data []byte) error {
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		statement := &PolicyStatement{}
+		err := json.Unmarshal(data, statement)
+		*s = PolicyStatements{statement}
+		return err
+	}
+	return json.Unmarshal(data, (*[]*PolicyStatement)(s))
+}
+
+// StringList is a list of strings which also accepts a single string when unmarshalled, like IAM does.
+type StringList []string
+
+func (s *StringList) UnmarshalJSON(data []byte) error {
+	if strings.HasPrefix(strings.TrimSpace(string(data)), `"`) {
+		var it string
+		err := json.Unmarshal(data, &it)
+		*s = StringList{it}
+		return err
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// ARNPlaceholders holds the values substituted into ARN templates. Empty values leave the placeholder untouched.
+type ARNPlaceholders struct {
+	Partition string
+	Region    string
+	Account   string
+}
+
+// ServiceAction is an action together with the service it belongs to.
+type ServiceAction struct {
+	Service *Service
+	Action  *Action
+}
+
+func (it *ServiceAction) FullName() string {
+	return fmt.Sprintf("%s:%s", it.Service.Prefix, it.Action.Name)
+}
+
+func fillARN(template string, placeholders ARNPlaceholders) string {
+	replacements := []string{}
+	if len(placeholders.Partition) > 0 {
+		replacements = append(replacements, "${Partition}", placeholders.Partition)
+	}
+	if len(placeholders.Region) > 0 {
+		replacements = append(replacements, "${Region}", placeholders.Region)
+	}
+	if len(placeholders.Account) > 0 {
+		replacements = append(replacements, "${Account}", placeholders.Account)
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// policyResources returns the ARN templates of the resource types the action requires, or "*" if it requires none.
+func policyResources(serviceAction *ServiceAction, placeholders ARNPlaceholders) []string {
+	resources := []string{}
+	for _, reference := range serviceAction.Action.ResourceTypeReferences {
+		if !reference.Required {
+			continue
+		}
+		for _, resourceType := range serviceAction.Service.ResourceTypes {
+			if resourceType.Name == reference.Name {
+				resources = append(resources, fillARN(resourceType.ARN, placeholders))
+			}
+		}
+	}
+	if len(resources) == 0 {
+		return []string{"*"}
+	}
+	resources = unique(resources)
+	sort.Strings(resources)
+	return resources
+}
+
+// buildPolicy creates a policy allowing the actions. Actions which need the same resources share a statement.
+func buildPolicy(serviceActions []*ServiceAction, placeholders ARNPlaceholders) *PolicyDocument {
+	policy := &PolicyDocument{Version: POLICY_VERSION, Statement: make([]*PolicyStatement, 0)}
+	statements := map[string]*PolicyStatement{}
+	for _, serviceAction := range serviceActions {
+		resources := policyResources(serviceAction, placeholders)
+		key := strings.Join(resources, "\n")
+		statement, ok := statements[key]
+		if !ok {
+			statement = &PolicyStatement{Effect: "Allow", Action: []string{}, Resource: resources}
+			statements[key] = statement
+			policy.Statement = append(policy.Statement, statement)
+		}
+		statement.Action = append(statement.Action, serviceAction.FullName())
+	}
+
+	for _, statement := range policy.Statement {
+		statement.Action = unique(statement.Action)
+		sort.Strings(statement.Action)
+	}
+	return policy
+}
+
+func renderPolicy(policy *PolicyDocument) (string, error) {
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func runPolicy(opts *Options, args []string) error {
+	flags := newFlagSet("policy", "policy [--dependencies] <prefix:action>...")
+	dependencies := flags.Bool("dependencies", false, "also allow every action the actions depend on")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one action")
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+
+	serviceActions := make([]*ServiceAction, 0)
+	for _, it := range flags.Args() {
+		service, action, err := findAction(it, services)
+		if err != nil {
+			return err
+		}
+		serviceActions = append(serviceActions, &ServiceAction{Service: service, Action: action})
+	}
+	if *dependencies {
+		for _, serviceAction := range serviceActions {
+			for _, it := range dependencyClosure(serviceAction, services) {
+				if it.ServiceAction == nil {
+					fmt.Fprintf(os.Stderr, "warning: %s depends on the undocumented action %s\n", it.RequiredBy, it.Name)
+					continue
+				}
+				serviceActions = append(serviceActions, it.ServiceAction)
+			}
+		}
+	}
+
+	policy, err := renderPolicy(buildPolicy(serviceActions, opts.Placeholders))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, policy)
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPolicy(t *testing.T) {
+	services := testServices()
+	serviceActions := []*ServiceAction{}
+	for _, it := range []string{"s3:PutObject", "iam:PassRole", "s3:GetObject", "s3:ListBucket"} {
+		service, action, err := findAction(it, services)
+		assert.NoError(t, err)
+		serviceActions = append(serviceActions, &ServiceAction{Service: service, Action: action})
+	}
+
+	policy := buildPolicy(serviceActions, ARNPlaceholders{Partition: "aws", Account: "123456789012"})
+
+	expected := &PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []*PolicyStatement{
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:GetObject", "s3:PutObject"},
+				Resource: []string{"arn:aws:s3:::${BucketName}/${ObjectName}"},
+			},
+			{
+				Effect:   "Allow",
+				Action:   []string{"iam:PassRole"},
+				Resource: []string{"arn:aws:iam::123456789012:role/${RoleNameWithPath}"},
+			},
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:ListBucket"},
+				Resource: []string{"arn:aws:s3:::${BucketName}"},
+			},
+		},
+	}
+	assert.Equal(t, expected, policy)
+}
+
+func TestPolicyResourcesWithoutRequiredResourceType(t *testing.T) {
+	service := &Service{Prefix: "sts"}
+	action := &Action{Name: "GetCallerIdentity", ResourceTypeReferences: []*ResourceTypeReference{}}
+	assert.Equal(t, []string{"*"}, policyResources(&ServiceAction{Service: service, Action: action}, ARNPlaceholders{}))
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Placeholders of ARN templates which stand for a single field of the ARN
+var ARN_FIELD_PLACEHOLDERS = map[string]bool{"${Partition}": true, "${Region}": true, "${Account}": true}
+
+var arnPlaceholderRegexp = regexp.MustCompile(`\$\{[^}]*\}`)
+
+// ServiceResourceType is a resource type together with the service it belongs to.
+type ServiceResourceType struct {
+	Service      *Service
+	ResourceType *ResourceType
+}
+
+func (it *ServiceResourceType) FullName() string {
+	return fmt.Sprintf("%s:%s", it.Service.Prefix, it.ResourceType.Name)
+}
+
+// ResourceTypeLookup is the machine-readable form of everything renderResourceType shows for a resource type.
+type ResourceTypeLookup struct {
+	Service      ServiceSummary
+	ResourceType *ResourceType
+	Actions      []*ResourceTypeAction
+}
+
+type ResourceTypeAction struct {
+	Name        string
+	AccessLevel string
+	Required    bool
+}
+
+// compileARNTemplate compiles an ARN template like "arn:${Partition}:s3:::${BucketName}" into a regexp matching the
+// ARNs it describes. It also returns the number of literal characters in the template, the more there are the more
+// specific the template is.
+func compileARNTemplate(template string) (*regexp.Regexp, int) {
+	expr := &strings.Builder{}
+	expr.WriteString("^")
+	literals := 0
+	last := 0
+	for _, loc := range arnPlaceholderRegexp.FindAllStringIndex(template, -1) {
+		expr.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		literals += loc[0] - last
+		if ARN_FIELD_PLACEHOLDERS[template[loc[0]:loc[1]]] {
+			expr.WriteString("[^:]*")
+		} else {
+			// Resource IDs may contain slashes and colons, like object keys or function versions
+			expr.WriteString(".+")
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(template[last:]))
+	literals += len(template) - last
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String()), literals
+}
+
+// ResourceTypeIndex resolves ARNs and resource type names to resource types. The ARN templates are compiled on the
+// first ARN lookup and reused afterwards.
+type ResourceTypeIndex struct {
+	services  []*Service
+	templates []*arnTemplate
+}
+
+type arnTemplate struct {
+	serviceResourceType *ServiceResourceType
+	re                  *regexp.Regexp
+	literals            int
+}
+
+func NewResourceTypeIndex(services []*Service) *ResourceTypeIndex {
+	return &ResourceTypeIndex{services: services}
+}
+
+func (index *ResourceTypeIndex) arnTemplates() []*arnTemplate {
+	if index.templates == nil {
+		index.templates = make([]*arnTemplate, 0)
+		for _, service := range index.services {
+			for _, resourceType := range service.ResourceTypes {
+				re, literals := compileARNTemplate(resourceType.ARN)
+				index.templates = append(index.templates, &arnTemplate{
+					serviceResourceType: &ServiceResourceType{Service: service, ResourceType: resourceType},
+					re:                  re,
+					literals:            literals,
+				})
+			}
+		}
+	}
+	return index.templates
+}
+
+// Find resolves an ARN or a resource type name like "s3:bucket" or "bucket" to the resource types it refers to. An
+// ARN matches the resource types with the most specific ARN template matching it, because templates overlap:
+// "arn:aws:s3:::bucket/key" matches both the bucket and the object template.
+func (index *ResourceTypeIndex) Find(query string) []*ServiceResourceType {
+	query = strings.TrimSpace(query)
+	matches := make([]*ServiceResourceType, 0)
+	if strings.HasPrefix(query, "arn:") {
+		best := 0
+		for _, it := range index.arnTemplates() {
+			if it.literals < best || !it.re.MatchString(query) {
+				continue
+			}
+			if it.literals > best {
+				best = it.literals
+				matches = matches[:0]
+			}
+			matches = append(matches, it.serviceResourceType)
+		}
+		return matches
+	}
+
+	prefix, name, found := strings.Cut(strings.ToLower(query), ":")
+	if !found {
+		prefix, name = "", prefix
+	}
+	for _, service := range index.services {
+		if len(prefix) > 0 && strings.ToLower(service.Prefix) != prefix {
+			continue
+		}
+		for _, resourceType := range service.ResourceTypes {
+			if strings.ToLower(resourceType.Name) == name {
+				matches = append(matches, &ServiceResourceType{Service: service, ResourceType: resourceType})
+			}
+		}
+	}
+	return matches
+}
+
+// findResourceTypes resolves a single query, see ResourceTypeIndex.Find.
+func findResourceTypes(query string, services []*Service) []*ServiceResourceType {
+	return NewResourceTypeIndex(services).Find(query)
+}
+
+// actionsForResourceType returns every action of the service that can be scoped to the resource type, sorted by name.
+func actionsForResourceType(serviceResourceType *ServiceResourceType) []*ServiceAction {
+	serviceActions := make([]*ServiceAction, 0)
+	service := serviceResourceType.Service
+	for _, serviceAction := range expandWildcard(service.Prefix+":*", []*Service{service}) {
+		if resourceTypeReference(serviceAction.Action, serviceResourceType.ResourceType.Name) != nil {
+			serviceActions = append(serviceActions, serviceAction)
+		}
+	}
+	return serviceActions
+}
+
+func resourceTypeReference(action *Action, name string) *ResourceTypeReference {
+	for _, it := range action.ResourceTypeReferences {
+		if it.Name == name {
+			return it
+		}
+	}
+	return nil
+}
+
+func newResourceTypeLookup(serviceResourceType *ServiceResourceType) *ResourceTypeLookup {
+	lookup := &ResourceTypeLookup{
+		Service:      ServiceSummary{Name: serviceResourceType.Service.Name, Prefix: serviceResourceType.Service.Prefix},
+		ResourceType: serviceResourceType.ResourceType,
+		Actions:      make([]*ResourceTypeAction, 0),
+	}
+	for _, it := range actionsForResourceType(serviceResourceType) {
+		lookup.Actions = append(lookup.Actions, &ResourceTypeAction{
+			Name:        it.FullName(),
+			AccessLevel: it.Action.AccessLevel,
+			Required:    resourceTypeReference(it.Action, serviceResourceType.ResourceType.Name).Required,
+		})
+	}
+	return lookup
+}
+
+func renderResourceType(serviceResourceType *ServiceResourceType) string {
+	lookup := newResourceTypeLookup(serviceResourceType)
+	message := fmt.Sprintf(
+		`[::b]Service:[::-] %s
+[::b]Resource Type[::-]: %s
+[::b]ARN[::-]: %s
+[::b]Condition Keys[::-]: %s`,
+		lookup.Service.Name,
+		serviceResourceType.FullName(),
+		lookup.ResourceType.ARN,
+		joinConditionKeys(lookup.ResourceType.ConditionKeys),
+	)
+	if len(lookup.Actions) == 0 {
+		return message + "\n\nNo actions can be scoped to this resource type"
+	}
+
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"Action", "Access Level", "Required"})
+	table.SetColWidth(100)
+	for _, it := range lookup.Actions {
+		required := ""
+		if it.Required {
+			required = "yes"
+		}
+		table.Append([]string{it.Name, it.AccessLevel, required})
+	}
+	table.Render()
+	return fmt.Sprintf("%s\n\n[::b]%d Actions[::-]\n%s", message, len(lookup.Actions), tableString)
+}
+
+func renderResourceTypes(query string, matches []*ServiceResourceType) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("[::b]%s[::-] matches no resource types", cview.Escape(query))
+	}
+	rendered := make([]string, 0, len(matches))
+	for _, it := range matches {
+		rendered = append(rendered, renderResourceType(it))
+	}
+	return strings.Join(rendered, "\n\n")
+}
+
+func runResource(opts *Options, args []string) error {
+	flags := newFlagSet("resource", "resource [--output text|json] <arn|prefix:resource-type>")
+	output := flags.String("output", "text", "output format, either text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one ARN or resource type, got %d", flags.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+
+	matches := findResourceTypes(flags.Arg(0), services)
+	if len(matches) == 0 {
+		return fmt.Errorf("no resource type matches %q", flags.Arg(0))
+	}
+
+	if *output == "json" {
+		lookups := make([]*ResourceTypeLookup, 0, len(matches))
+		for _, it := range matches {
+			lookups = append(lookups, newResourceTypeLookup(it))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(lookups)
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, formatForOutput(renderResourceTypes(flags.Arg(0), matches), os.Stdout))
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resourceTypeNames(matches []*ServiceResourceType) []string {
+	names := []string{}
+	for _, it := range matches {
+		names = append(names, it.FullName())
+	}
+	return names
+}
+
+func TestFindResourceTypes(t *testing.T) {
+	services := testServices()
+
+	assert.Equal(t, []string{"s3:object"}, resourceTypeNames(findResourceTypes("arn:aws:s3:::my-bucket/some/key", services)))
+	assert.Equal(t, []string{"s3:bucket"}, resourceTypeNames(findResourceTypes("arn:aws:s3:::my-bucket", services)))
+	assert.Equal(t, []string{"iam:role"}, resourceTypeNames(findResourceTypes("arn:aws-cn:iam::123456789012:role/path/app", services)))
+	assert.Empty(t, findResourceTypes("arn:aws:iam::123456789012:user/app", services))
+
+	assert.Equal(t, []string{"s3:bucket"}, resourceTypeNames(findResourceTypes("S3:Bucket", services)))
+	assert.Equal(t, []string{"iam:role"}, resourceTypeNames(findResourceTypes("role", services)))
+	assert.Empty(t, findResourceTypes("iam:bucket", services))
+}
+
+func TestResourceTypeIndex(t *testing.T) {
+	index := NewResourceTypeIndex(testServices())
+
+	assert.Equal(t, []string{"s3:bucket"}, resourceTypeNames(index.Find("s3:bucket")))
+	assert.Nil(t, index.templates)
+
+	assert.Equal(t, []string{"s3:object"}, resourceTypeNames(index.Find("arn:aws:s3:::my-bucket/some/key")))
+	templates := index.templates
+	assert.Len(t, templates, 3)
+	assert.Equal(t, []string{"s3:bucket"}, resourceTypeNames(index.Find("arn:aws:s3:::my-bucket")))
+	assert.Same(t, &templates[0], &index.templates[0])
+}
+
+func TestNewResourceTypeLookup(t *testing.T) {
+	matches := findResourceTypes("s3:object", testServices())
+	lookup := newResourceTypeLookup(matches[0])
+
+	assert.Equal(t, ServiceSummary{Name: "Amazon S3", Prefix: "s3"}, lookup.Service)
+	// GetObject spans two rows but must only be listed once
+	assert.Equal(t, []*ResourceTypeAction{
+		{Name: "s3:GetObject", AccessLevel: "Read", Required: true},
+		{Name: "s3:PutObject", AccessLevel: "Write", Required: true},
+	}, lookup.Actions)
+}
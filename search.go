@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// How much a token counts depending on where it occurs in an action
+const SEARCH_WEIGHT_NAME = 3.0
+const SEARCH_WEIGHT_PREFIX = 2.0
+const SEARCH_WEIGHT_DESCRIPTION = 1.0
+
+// Tokens of a query which are not typed out yet only match with this fraction of their weight
+const SEARCH_WEIGHT_PARTIAL = 0.5
+
+// Words that occur in almost every description and don't help ranking
+var SEARCH_STOPWORDS = map[string]bool{
+	"a": true, "an": true, "and": true, "any": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "grant": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"permission": true, "specified": true, "that": true, "the": true, "this": true, "to": true, "with": true,
+}
+
+type searchPosting struct {
+	entry  int
+	weight float64
+}
+
+// SearchIndex is a ranked full-text index over the names and descriptions of all actions.
+type SearchIndex struct {
+	entries  []*ServiceAction
+	postings map[string][]searchPosting
+	// all indexed tokens, sorted, to find the tokens starting with a prefix
+	tokens []string
+}
+
+type SearchResult struct {
+	ServiceAction *ServiceAction
+	Score         float64
+}
+
+func NewSearchIndex(services []*Service) *SearchIndex {
+	index := &SearchIndex{
+		entries:  make([]*ServiceAction, 0),
+		postings: map[string][]searchPosting{},
+	}
+
+	for _, service := range services {
+		for _, serviceAction := range expandWildcard(service.Prefix+":*", []*Service{service}) {
+			entry := len(index.entries)
+			index.entries = append(index.entries, serviceAction)
+
+			weights := map[string]float64{}
+			for _, token := range tokenize(service.Prefix) {
+				weights[token] += SEARCH_WEIGHT_PREFIX
+			}
+			for _, token := range tokenize(serviceAction.Action.Name) {
+				weights[token] += SEARCH_WEIGHT_NAME
+			}
+			for _, token := range tokenize(serviceAction.Action.Description) {
+				weights[token] += SEARCH_WEIGHT_DESCRIPTION
+			}
+			for token, weight := range weights {
+				index.postings[token] = append(index.postings[token], searchPosting{entry: entry, weight: weight})
+			}
+		}
+	}
+
+	index.tokens = make([]string, 0, len(index.postings))
+	for token := range index.postings {
+		index.tokens = append(index.tokens, token)
+	}
+	sort.Strings(index.tokens)
+
+	return index
+}
+
+// Search returns the actions matching the query, best match first. With partial set, the last word of the query also
+// matches words it is a prefix of, so results show up while typing.
+func (index *SearchIndex) Search(query string, partial bool) []*SearchResult {
+	queryTokens := tokenize(query)
+	scores := map[int]float64{}
+	for i, queryToken := range queryTokens {
+		matches := map[string]float64{queryToken: 1}
+		if partial && i == len(queryTokens)-1 {
+			for _, token := range index.tokensWithPrefix(queryToken) {
+				if token != queryToken {
+					matches[token] = SEARCH_WEIGHT_PARTIAL
+				}
+			}
+		}
+
+		for token, factor := range matches {
+			postings := index.postings[token]
+			if len(postings) == 0 {
+				continue
+			}
+			idf := math.Log(1 + float64(len(index.entries))/float64(len(postings)))
+			for _, posting := range postings {
+				scores[posting.entry] += factor * idf * posting.weight
+			}
+		}
+	}
+
+	results := make([]*SearchResult, 0, len(scores))
+	for entry, score := range scores {
+		results = append(results, &SearchResult{ServiceAction: index.entries[entry], Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ServiceAction.FullName() < results[j].ServiceAction.FullName()
+	})
+	return results
+}
+
+func (index *SearchIndex) tokensWithPrefix(prefix string) []string {
+	start := sort.SearchStrings(index.tokens, prefix)
+	end := start
+	for end < len(index.tokens) && strings.HasPrefix(index.tokens[end], prefix) {
+		end++
+	}
+	return index.tokens[start:end]
+}
+
+// tokenize splits text into lowercase words, also splitting camel case words like action names into their parts, and
+// drops stopwords. Plurals are reduced to their singular so "policies" finds "policy".
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		for _, part := range splitCamelCase(word) {
+			word := strings.ToLower(part)
+			token := stem(word)
+			if len(token) > 0 && !SEARCH_STOPWORDS[word] && !SEARCH_STOPWORDS[token] {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// splitCamelCase splits "AttachRolePolicy" into "Attach", "Role", "Policy" and "ListMFADevices" into "List", "MFA",
+// "Devices".
+func splitCamelCase(word string) []string {
+	runes := []rune(word)
+	parts := make([]string, 0)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+func runSearch(opts *Options, args []string) error {
+	flags := newFlagSet("search", "search [--limit n] <words>...")
+	limit := flags.Int("limit", 10, "maximum number of results")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *limit <= 0 {
+		flags.Usage()
+		return fmt.Errorf("invalid limit %d: expected a positive number", *limit)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one word to search for")
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+
+	query, filter := parseAccessLevelFilter(strings.Join(flags.Args(), " "))
+	serviceActions := make([]*ServiceAction, 0)
+	for _, it := range NewSearchIndex(services).Search(query, false) {
+		if len(serviceActions) >= *limit {
+			break
+		}
+		if filter.Matches(it.ServiceAction.Action.AccessLevel) {
+			serviceActions = append(serviceActions, it.ServiceAction)
+		}
+	}
+	if len(serviceActions) == 0 {
+		return fmt.Errorf("no actions match %q", strings.Join(flags.Args(), " "))
+	}
+	_, err = fmt.Fprint(os.Stdout, renderActionTable(serviceActions))
+	return err
+}
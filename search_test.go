@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func searchNames(results []*SearchResult) []string {
+	names := []string{}
+	for _, it := range results {
+		names = append(names, it.ServiceAction.FullName())
+	}
+	return names
+}
+
+func TestSearchIndex(t *testing.T) {
+	index := NewSearchIndex(testServices())
+
+	assert.Equal(t, "iam:AttachRolePolicy", searchNames(index.Search("attach a policy to a role", false))[0])
+	assert.Equal(t, []string{"s3:GetObject"}, searchNames(index.Search("retrieve objects", false))[:1])
+
+	// GetObject spans two rows but must only be indexed once
+	assert.Equal(t, []string{"s3:GetObject", "s3:PutObject", "s3:ListBucket"}, searchNames(index.Search("object", false)))
+
+	// The last word only matches the words it is the start of while it is being typed
+	assert.Empty(t, index.Search("retri", false))
+	assert.Equal(t, []string{"s3:GetObject"}, searchNames(index.Search("retri", true)))
+
+	assert.Empty(t, index.Search("grants permission to the", true))
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"attach", "role", "policy"}, tokenize("AttachRolePolicy"))
+	assert.Equal(t, []string{"list", "mfa", "device"}, tokenize("ListMFADevices"))
+	assert.Equal(t, []string{"retrieve", "object", "amazon", "s3"}, tokenize("Grants permission to retrieve objects from Amazon S3"))
+	assert.Equal(t, []string{"policy", "access"}, tokenize("policies, access"))
+}
+
+func TestRunSearchRejectsInvalidLimit(t *testing.T) {
+	assert.ErrorContains(t, runSearch(&Options{}, []string{"--limit", "0", "role"}), "invalid limit 0")
+	assert.ErrorContains(t, runSearch(&Options{}, []string{"--limit", "-1", "role"}), "invalid limit -1")
+}
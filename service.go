@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// The access levels in the order the IAM documentation explains them
+var ACCESS_LEVELS = []string{"List", "Read", "Write", "Permissions management", "Tagging"}
+
+// findServiceByQuery returns the service a query like "s3:" names, if any.
+func findServiceByQuery(query string, services []*Service) *Service {
+	prefix, rest, found := strings.Cut(strings.TrimSpace(query), ":")
+	if !found || len(rest) > 0 {
+		return nil
+	}
+	for _, service := range services {
+		if strings.EqualFold(service.Prefix, prefix) {
+			return service
+		}
+	}
+	return nil
+}
+
+// groupByAccessLevel groups the actions by their access level. The access levels are returned in the order of
+// ACCESS_LEVELS, followed by any unknown ones sorted by name.
+func groupByAccessLevel(serviceActions []*ServiceAction) ([]string, map[string][]*ServiceAction) {
+	groups := map[string][]*ServiceAction{}
+	for _, it := range serviceActions {
+		groups[it.Action.AccessLevel] = append(groups[it.Action.AccessLevel], it)
+	}
+
+	accessLevels := make([]string, 0, len(groups))
+	for _, it := range ACCESS_LEVELS {
+		if len(groups[it]) > 0 {
+			accessLevels = append(accessLevels, it)
+		}
+	}
+	unknown := make([]string, 0)
+	for it := range groups {
+		if !contains(ACCESS_LEVELS, it) {
+			unknown = append(unknown, it)
+		}
+	}
+	sort.Strings(unknown)
+	return append(accessLevels, unknown...), groups
+}
+
+func contains(array []string, s string) bool {
+	for _, it := range array {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
+
+// renderService renders an overview of a service: the given actions of it grouped by access level, its resource
+// types, and its condition keys.
+func renderService(service *Service, serviceActions []*ServiceAction) string {
+	sections := []string{fmt.Sprintf(
+		`[::b]Service[::-]: %s
+[::b]Prefix[::-]: %s
+[::b]Documentation[::-]: %s
+[::b]Actions[::-]: %d
+[::b]Resource Types[::-]: %d
+[::b]Condition Keys[::-]: %d
+`,
+		service.Name,
+		service.Prefix,
+		service.URL,
+		len(serviceActions),
+		len(service.ResourceTypes),
+		len(service.ConditionKeys),
+	)}
+
+	accessLevels, groups := groupByAccessLevel(serviceActions)
+	for _, accessLevel := range accessLevels {
+		tableString := &strings.Builder{}
+		table := tablewriter.NewWriter(tableString)
+		table.SetHeader([]string{"Action", "Description"})
+		table.SetColWidth(100)
+		for _, it := range groups[accessLevel] {
+			table.Append([]string{it.FullName(), it.Action.Description})
+		}
+		table.Render()
+		sections = append(sections, fmt.Sprintf("[::b]%s Actions (%d)[::-]\n%s", accessLevel, len(groups[accessLevel]), tableString))
+	}
+
+	if len(service.ResourceTypes) > 0 {
+		tableString := &strings.Builder{}
+		table := tablewriter.NewWriter(tableString)
+		table.SetHeader([]string{"Resource Type", "ARN", "Condition Keys"})
+		table.SetRowLine(true)
+		table.SetRowSeparator("-")
+		table.SetColWidth(100)
+		for _, resourceType := range service.ResourceTypes {
+			table.Append([]string{
+				resourceType.Name,
+				breakString(resourceType.ARN, 80),
+				joinConditionKeys(resourceType.ConditionKeys),
+			})
+		}
+		table.Render()
+		sections = append(sections, fmt.Sprintf("[::b]Resource Types[::-]\n%s", tableString))
+	}
+
+	if len(service.ConditionKeys) > 0 {
+		tableString := &strings.Builder{}
+		table := tablewriter.NewWriter(tableString)
+		table.SetHeader([]string{"Condition Key", "Description", "Type"})
+		table.SetRowLine(true)
+		table.SetRowSeparator("-")
+		table.SetColWidth(100)
+		for _, conditionKey := range service.ConditionKeys {
+			table.Append([]string{conditionKey.Name, conditionKey.Description, conditionKey.Type})
+		}
+		table.Render()
+		sections = append(sections, fmt.Sprintf("[::b]Condition Keys[::-]\n%s", tableString))
+	}
+
+	// Every section ends in a newline already
+	return strings.Join(sections, "\n")
+}
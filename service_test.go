@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindServiceByQuery(t *testing.T) {
+	services := testServices()
+
+	assert.Equal(t, "s3", findServiceByQuery("s3:", services).Prefix)
+	assert.Equal(t, "iam", findServiceByQuery(" IAM: ", services).Prefix)
+	assert.Nil(t, findServiceByQuery("s3", services))
+	assert.Nil(t, findServiceByQuery("s3:Get", services))
+	assert.Nil(t, findServiceByQuery("ec2:", services))
+}
+
+func TestGroupByAccessLevel(t *testing.T) {
+	services := testServices()
+	serviceActions := append(expandWildcard("*", services), &ServiceAction{
+		Service: services[0],
+		Action:  &Action{Name: "Unusual", AccessLevel: "Unusual"},
+	})
+
+	accessLevels, groups := groupByAccessLevel(serviceActions)
+	assert.Equal(t, []string{"List", "Read", "Write", "Permissions management", "Unusual"}, accessLevels)
+	assert.Len(t, groups["Write"], 2)
+}
+
+func TestRenderService(t *testing.T) {
+	service := testServices()[0]
+	serviceActions := expandWildcard(service.Prefix+":*", []*Service{service})
+	rendered := renderService(service, serviceActions)
+	assert.Contains(t, rendered, "[::b]Actions[::-]: 3\n")
+	assert.Contains(t, rendered, "[::b]Read Actions (1)[::-]")
+	assert.Contains(t, rendered, "arn:${Partition}:s3:::${BucketName}/${ObjectName}")
+	assert.Contains(t, rendered, "s3:x-amz-acl")
+
+	// Filtered by access level
+	_, filter := parseAccessLevelFilter("level:write")
+	rendered = renderService(service, filter.Apply(serviceActions))
+	assert.Contains(t, rendered, "[::b]Actions[::-]: 1\n")
+	assert.NotContains(t, rendered, "Read Actions")
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const SNAPSHOTS_DIR = "snapshots"
+const SNAPSHOT_MANIFEST_PATH = "manifest.json"
+
+// Snapshot IDs sort chronologically, start with the date, and are valid file names on every platform
+const SNAPSHOT_ID_LAYOUT = "2006-01-02T150405Z"
+
+// Snapshot describes the data of one crawl, which is kept when the local data is replaced by a newer crawl.
+type Snapshot struct {
+	ID        string
+	CrawledAt time.Time
+	Version   string
+	Services  int
+	Actions   int
+}
+
+type snapshotManifest struct {
+	Snapshots []*Snapshot
+}
+
+func snapshotPath(projectDir string, id string) string {
+	return filepath.Join(projectDir, SNAPSHOTS_DIR, id+".json")
+}
+
+// loadSnapshots returns the snapshots in the manifest, oldest first. There are none if nothing was crawled yet.
+func loadSnapshots(projectDir string) ([]*Snapshot, error) {
+	path := filepath.Join(projectDir, SNAPSHOTS_DIR, SNAPSHOT_MANIFEST_PATH)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]*Snapshot, 0), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifest := &snapshotManifest{}
+	err = json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sort.SliceStable(manifest.Snapshots, func(i, j int) bool {
+		return manifest.Snapshots[i].ID < manifest.Snapshots[j].ID
+	})
+	return manifest.Snapshots, nil
+}
+
+func saveSnapshots(projectDir string, snapshots []*Snapshot) error {
+	data, err := json.MarshalIndent(&snapshotManifest{Snapshots: snapshots}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(projectDir, SNAPSHOTS_DIR, SNAPSHOT_MANIFEST_PATH), data)
+}
+
+// saveSnapshot saves the data crawled by the version as a new snapshot and adds it to the manifest.
+func saveSnapshot(services []*Service, crawledAt time.Time, version string, projectDir string) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		ID:        crawledAt.UTC().Format(SNAPSHOT_ID_LAYOUT),
+		CrawledAt: crawledAt.UTC(),
+		Version:   version,
+		Services:  len(services),
+		Actions:   len(buildActionNames(services)),
+	}
+	err := saveCrawl(services, snapshotPath(projectDir, snapshot.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := loadSnapshots(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	// A snapshot taken within the same second replaces the previous one
+	kept := make([]*Snapshot, 0, len(snapshots)+1)
+	for _, it := range snapshots {
+		if it.ID != snapshot.ID {
+			kept = append(kept, it)
+		}
+	}
+	return snapshot, saveSnapshots(projectDir, append(kept, snapshot))
+}
+
+// importLegacyData saves the local data crawled before snapshots were taken as the first snapshot, so it is kept when
+// the local data is replaced. Nothing is imported once there are snapshots or if there is no local data.
+func importLegacyData(projectDir string) error {
+	snapshots, err := loadSnapshots(projectDir)
+	if err != nil || len(snapshots) > 0 {
+		return err
+	}
+	services, err := readServices(filepath.Join(projectDir, RAW_DATA_PATH))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		// Unreadable data is replaced by the crawl anyway
+		fmt.Fprintf(os.Stderr, "warning: couldn't keep the previous local data as a snapshot: %v\n", err)
+		return nil
+	}
+	crawledAt, err := loadCrawledAt(projectDir)
+	if err != nil {
+		return err
+	}
+	version, err := os.ReadFile(filepath.Join(projectDir, VERSION_PATH))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	_, err = saveSnapshot(services, crawledAt, strings.TrimSpace(string(version)), projectDir)
+	return err
+}
+
+// findSnapshot returns the snapshot with the ID, or the newest one whose ID starts with it, so a date like
+// "2024-05-01" picks the last crawl of that day.
+func findSnapshot(id string, snapshots []*Snapshot) (*Snapshot, error) {
+	var found *Snapshot
+	for _, it := range snapshots {
+		if it.ID == id {
+			return it, nil
+		}
+		if strings.HasPrefix(it.ID, id) && (found == nil || it.ID > found.ID) {
+			found = it
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no snapshot %q, run `iampolicyhelper snapshot list` to see the available ones", id)
+	}
+	return found, nil
+}
+
+func loadSnapshot(projectDir string, id string) (*Snapshot, error) {
+	snapshots, err := loadSnapshots(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return findSnapshot(id, snapshots)
+}
+
+// pruneSnapshots deletes the snapshots beyond the newest keep ones and those crawled more than olderThan before now.
+// A zero keep or olderThan disables that criterion. The deleted snapshots are returned.
+func pruneSnapshots(projectDir string, keep int, olderThan time.Duration, now time.Time) ([]*Snapshot, error) {
+	snapshots, err := loadSnapshots(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]*Snapshot, 0, len(snapshots))
+	pruned := make([]*Snapshot, 0)
+	for i, it := range snapshots {
+		newer := len(snapshots) - 1 - i
+		if (keep > 0 && newer >= keep) || (olderThan > 0 && now.Sub(it.CrawledAt) > olderThan) {
+			pruned = append(pruned, it)
+		} else {
+			kept = append(kept, it)
+		}
+	}
+	if len(pruned) == 0 {
+		return pruned, nil
+	}
+
+	// The manifest is saved first, so it never lists a deleted snapshot
+	err = saveSnapshots(projectDir, kept)
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range pruned {
+		err = os.Remove(snapshotPath(projectDir, it.ID))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return pruned, nil
+}
+
+func renderSnapshots(snapshots []*Snapshot) string {
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"ID", "Crawled At", "Version", "Services", "Actions"})
+	for _, it := range snapshots {
+		table.Append([]string{
+			it.ID,
+			it.CrawledAt.Local().Format(time.DateTime),
+			it.Version,
+			fmt.Sprint(it.Services),
+			fmt.Sprint(it.Actions),
+		})
+	}
+	table.Render()
+	return tableString.String()
+}
+
+func runSnapshot(opts *Options, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a snapshot command, either list or prune")
+	}
+
+	switch args[0] {
+	case "list":
+		return runSnapshotList(opts, args[1:])
+	case "prune":
+		return runSnapshotPrune(opts, args[1:])
+	default:
+		return fmt.Errorf("unknown snapshot command %q, expected list or prune", args[0])
+	}
+}
+
+func runSnapshotList(opts *Options, args []string) error {
+	flags := newFlagSet("snapshot list", "snapshot list [--output text|json]")
+	output := flags.String("output", "text", "output format, either text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	projectDir, err := getProjectDir(opts)
+	if err != nil {
+		return err
+	}
+	snapshots, err := loadSnapshots(projectDir)
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snapshots)
+	}
+	if len(snapshots) == 0 {
+		fmt.Fprintln(os.Stderr, "No snapshots, they are taken whenever the IAM documentation is crawled")
+		return nil
+	}
+	_, err = fmt.Fprint(os.Stdout, renderSnapshots(snapshots))
+	return err
+}
+
+func runSnapshotPrune(opts *Options, args []string) error {
+	flags := newFlagSet("snapshot prune", "snapshot prune [--keep <n>] [--older-than <age>]")
+	keep := flags.Int("keep", 0, "keep only this many of the newest snapshots")
+	olderThan := flags.String("older-than", "", "delete the snapshots older than this age (e.g. 90d or 12h)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("expected no arguments, got %d", flags.NArg())
+	}
+	if *keep < 0 {
+		return fmt.Errorf("invalid number of snapshots to keep %d", *keep)
+	}
+	var maxAge time.Duration
+	if len(*olderThan) > 0 {
+		maxAge, err = parseAge(*olderThan)
+		if err != nil {
+			return fmt.Errorf("invalid age %q: %w", *olderThan, err)
+		}
+	}
+	if *keep == 0 && maxAge <= 0 {
+		flags.Usage()
+		return fmt.Errorf("expected --keep or --older-than")
+	}
+
+	projectDir, err := getProjectDir(opts)
+	if err != nil {
+		return err
+	}
+	pruned, err := pruneSnapshots(projectDir, *keep, maxAge, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Deleted %d snapshots\n", len(pruned))
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	first := time.Date(2024, 5, 1, 7, 8, 9, 0, time.UTC)
+	second := time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)
+
+	snapshot, err := saveSnapshot(testServices(), first, VERSION_TAG, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &Snapshot{ID: "2024-05-01T070809Z", CrawledAt: first, Version: VERSION_TAG, Services: 2, Actions: 6}, snapshot)
+	_, err = saveSnapshot(testServices()[:1], second, VERSION_TAG, dir)
+	assert.NoError(t, err)
+	// Taken within the same second, so it replaces the previous one
+	_, err = saveSnapshot(testServices()[:1], second, VERSION_TAG, dir)
+	assert.NoError(t, err)
+
+	snapshots, err := loadSnapshots(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, "2024-05-01T070809Z", snapshots[0].ID)
+	assert.Equal(t, "2024-05-01T170000Z", snapshots[1].ID)
+
+	services, err := loadRawData(dir, "2024-05-01T070809Z")
+	assert.NoError(t, err)
+	assert.Len(t, services, 2)
+	// A date picks the last snapshot of that day
+	services, err = loadRawData(dir, "2024-05-01")
+	assert.NoError(t, err)
+	assert.Len(t, services, 1)
+	_, err = loadRawData(dir, "2024-05-02")
+	assert.ErrorContains(t, err, `no snapshot "2024-05-02"`)
+}
+
+func TestLoadSnapshotsWithoutManifest(t *testing.T) {
+	snapshots, err := loadSnapshots(t.TempDir())
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestCrawlAndSaveTakesSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	_, err := crawlAndSave(dir, func() ([]*Service, []*CrawlError, error) {
+		return testServices(), nil, nil
+	})
+	assert.NoError(t, err)
+
+	snapshots, err := loadSnapshots(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	crawledAt, err := loadCrawledAt(dir)
+	assert.NoError(t, err)
+	assert.True(t, crawledAt.Equal(snapshots[0].CrawledAt))
+
+	services, err := loadRawData(dir, snapshots[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, testServices(), services)
+}
+
+func TestCrawlAndSaveImportsLegacyData(t *testing.T) {
+	dir := t.TempDir()
+	legacyCrawledAt := time.Date(2024, 5, 1, 7, 8, 9, 0, time.UTC)
+	assert.NoError(t, saveCrawl(testServices()[:1], filepath.Join(dir, RAW_DATA_PATH)))
+	assert.NoError(t, saveCrawledAt(legacyCrawledAt, dir))
+	assert.NoError(t, saveVersion("v0.1.0", dir))
+
+	_, err := crawlAndSave(dir, func() ([]*Service, []*CrawlError, error) {
+		return testServices(), nil, nil
+	})
+	assert.NoError(t, err)
+
+	snapshots, err := loadSnapshots(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, &Snapshot{ID: "2024-05-01T070809Z", CrawledAt: legacyCrawledAt, Version: "v0.1.0", Services: 1, Actions: 4}, snapshots[0])
+	services, err := loadRawData(dir, snapshots[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, testServices()[:1], services)
+
+	// Only imported before the first snapshot
+	assert.NoError(t, importLegacyData(dir))
+	snapshots, err = loadSnapshots(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	setup := func() string {
+		dir := t.TempDir()
+		for _, days := range []int{100, 50, 10, 1} {
+			_, err := saveSnapshot(testServices(), now.Add(-time.Duration(days)*24*time.Hour), VERSION_TAG, dir)
+			assert.NoError(t, err)
+		}
+		return dir
+	}
+	ids := func(snapshots []*Snapshot) []string {
+		out := []string{}
+		for _, it := range snapshots {
+			out = append(out, it.ID)
+		}
+		return out
+	}
+
+	dir := setup()
+	pruned, err := pruneSnapshots(dir, 2, 0, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-02-22T000000Z", "2024-04-12T000000Z"}, ids(pruned))
+	snapshots, err := loadSnapshots(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-05-22T000000Z", "2024-05-31T000000Z"}, ids(snapshots))
+	_, err = os.Stat(snapshotPath(dir, "2024-02-22T000000Z"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	dir = setup()
+	pruned, err = pruneSnapshots(dir, 0, 30*24*time.Hour, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-02-22T000000Z", "2024-04-12T000000Z"}, ids(pruned))
+
+	dir = setup()
+	pruned, err = pruneSnapshots(dir, 3, 60*24*time.Hour, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-02-22T000000Z"}, ids(pruned))
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const SOURCE_DOCS = "docs"
+const SOURCE_DOCS_DIR_PREFIX = "docs-dir:"
+const SOURCE_SERVICE_REFERENCE_PREFIX = "service-reference:"
+const SOURCE_POLICY_GENERATOR_PREFIX = "policies-js:"
+
+// The policy generator's policies.js assigns its JSON config to this variable
+const POLICY_GENERATOR_ASSIGNMENT = "app.PolicyEditorConfig="
+
+// newCrawler creates the crawler of the data source described by spec: the IAM documentation on the AWS website, a
+// saved copy of it like "docs-dir:<dir>", the machine-readable service reference like "service-reference:<path>",
+// or the policy generator's config like "policies-js:<path>".
+func newCrawler(spec string) (Crawler, error) {
+	prefixes := []string{SOURCE_DOCS_DIR_PREFIX, SOURCE_SERVICE_REFERENCE_PREFIX, SOURCE_POLICY_GENERATOR_PREFIX}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(spec, prefix) && len(spec) == len(prefix) {
+			return nil, fmt.Errorf("expected a path after %s", prefix)
+		}
+	}
+
+	switch {
+	case spec == SOURCE_DOCS:
+		return crawl, nil
+	case strings.HasPrefix(spec, SOURCE_DOCS_DIR_PREFIX):
+		return func() ([]*Service, []*CrawlError, error) {
+			return crawlFromDir(strings.TrimPrefix(spec, SOURCE_DOCS_DIR_PREFIX))
+		}, nil
+	case strings.HasPrefix(spec, SOURCE_SERVICE_REFERENCE_PREFIX):
+		return func() ([]*Service, []*CrawlError, error) {
+			return crawlServiceReference(strings.TrimPrefix(spec, SOURCE_SERVICE_REFERENCE_PREFIX))
+		}, nil
+	case strings.HasPrefix(spec, SOURCE_POLICY_GENERATOR_PREFIX):
+		return func() ([]*Service, []*CrawlError, error) {
+			return crawlPolicyGenerator(strings.TrimPrefix(spec, SOURCE_POLICY_GENERATOR_PREFIX))
+		}, nil
+	default:
+		return nil, fmt.Errorf("expected %s, %s<dir>, %s<path>, or %s<path>", SOURCE_DOCS, SOURCE_DOCS_DIR_PREFIX, SOURCE_SERVICE_REFERENCE_PREFIX, SOURCE_POLICY_GENERATOR_PREFIX)
+	}
+}
+
+// serviceReference is a service in AWS's machine-readable service reference, see
+// https://docs.aws.amazon.com/service-authorization/latest/reference/service-reference.html
+type serviceReference struct {
+	Name    string
+	Actions []struct {
+		Name                string
+		ActionConditionKeys []string
+		Annotations         struct {
+			Properties struct {
+				IsList                 bool
+				IsPermissionManagement bool
+				IsTaggingOnly          bool
+				IsWrite                bool
+			}
+		}
+		Resources []struct {
+			Name string
+		}
+	}
+	Resources []struct {
+		Name          string
+		ARNFormats    []string
+		ConditionKeys []string
+	}
+	ConditionKeys []struct {
+		Name  string
+		Types []string
+	}
+}
+
+// crawlServiceReference reads the service reference JSON file at path, or all of them if path is a directory. Files
+// that can't be read are reported and skipped.
+func crawlServiceReference(path string) ([]*Service, []*CrawlError, error) {
+	paths, err := jsonFiles(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	services := make([]*Service, 0, len(paths))
+	crawlErrors := make([]*CrawlError, 0)
+	for _, it := range paths {
+		service, err := readServiceReference(it)
+		if err != nil {
+			crawlErrors = append(crawlErrors, &CrawlError{URL: it, Err: err})
+			continue
+		}
+		services = append(services, service)
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services, crawlErrors, nil
+}
+
+// jsonFiles returns path if it is a file, otherwise the JSON files in the directory at path.
+func jsonFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	return filepath.Glob(filepath.Join(path, "*.json"))
+}
+
+func readServiceReference(path string) (*Service, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	reference := &serviceReference{}
+	err = json.Unmarshal(data, reference)
+	if err != nil {
+		return nil, err
+	}
+	if len(reference.Name) == 0 {
+		return nil, fmt.Errorf("missing service name")
+	}
+
+	// The service reference has neither descriptions nor the full service names
+	service := &Service{
+		URL:           path,
+		Name:          reference.Name,
+		Prefix:        reference.Name,
+		Actions:       make([]*Action, 0, len(reference.Actions)),
+		ResourceTypes: make([]*ResourceType, 0, len(reference.Resources)),
+		ConditionKeys: make([]*ConditionKey, 0, len(reference.ConditionKeys)),
+	}
+	for _, it := range reference.Actions {
+		properties := it.Annotations.Properties
+		accessLevel := "Read"
+		switch {
+		case properties.IsPermissionManagement:
+			accessLevel = "Permissions management"
+		case properties.IsTaggingOnly:
+			accessLevel = "Tagging"
+		case properties.IsWrite:
+			accessLevel = "Write"
+		case properties.IsList:
+			accessLevel = "List"
+		}
+		action := &Action{
+			Name:                   it.Name,
+			AccessLevel:            accessLevel,
+			ResourceTypeReferences: make([]*ResourceTypeReference, 0, len(it.Resources)),
+			ConditionKeys:          append([]string{}, it.ActionConditionKeys...),
+			DependentActions:       make([]string, 0),
+		}
+		for _, resource := range it.Resources {
+			action.ResourceTypeReferences = append(action.ResourceTypeReferences, &ResourceTypeReference{Name: resource.Name})
+		}
+		service.Actions = append(service.Actions, action)
+	}
+	for _, it := range reference.Resources {
+		resourceType := &ResourceType{Name: it.Name, ConditionKeys: append([]string{}, it.ConditionKeys...)}
+		if len(it.ARNFormats) > 0 {
+			resourceType.ARN = it.ARNFormats[0]
+		}
+		service.ResourceTypes = append(service.ResourceTypes, resourceType)
+	}
+	for _, it := range reference.ConditionKeys {
+		service.ConditionKeys = append(service.ConditionKeys, &ConditionKey{Name: it.Name, Type: strings.Join(it.Types, ", ")})
+	}
+	return service, nil
+}
+
+// policyGeneratorConfig is the part of the AWS Policy Generator's policies.js describing the services.
+type policyGeneratorConfig struct {
+	ServiceMap map[string]struct {
+		StringPrefix  string
+		Actions       []string
+		ConditionKeys []string `json:"conditionKeys"`
+	} `json:"serviceMap"`
+}
+
+// crawlPolicyGenerator reads the policies.js file of the AWS Policy Generator at path. It only knows the names of the
+// actions and condition keys, so everything else is left empty.
+func crawlPolicyGenerator(path string) ([]*Service, []*CrawlError, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, config, found := strings.Cut(string(data), POLICY_GENERATOR_ASSIGNMENT)
+	if !found {
+		return nil, nil, fmt.Errorf("%s: missing %s", path, POLICY_GENERATOR_ASSIGNMENT)
+	}
+	parsed := &policyGeneratorConfig{}
+	// The assignment may be followed by a semicolon and a newline
+	err = json.NewDecoder(strings.NewReader(config)).Decode(parsed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Some prefixes are used by several services, which are merged because services are identified by their prefix
+	byPrefix := map[string]*Service{}
+	// the actions and condition keys already added, by service prefix
+	seen := map[string]bool{}
+	for _, name := range sortedKeys(parsed.ServiceMap) {
+		it := parsed.ServiceMap[name]
+		service, ok := byPrefix[it.StringPrefix]
+		if !ok {
+			service = &Service{
+				URL:           path,
+				Name:          name,
+				Prefix:        it.StringPrefix,
+				Actions:       make([]*Action, 0),
+				ResourceTypes: make([]*ResourceType, 0),
+				ConditionKeys: make([]*ConditionKey, 0),
+			}
+			byPrefix[it.StringPrefix] = service
+		}
+		for _, actionName := range it.Actions {
+			if seen[service.Prefix+":"+strings.ToLower(actionName)] {
+				continue
+			}
+			seen[service.Prefix+":"+strings.ToLower(actionName)] = true
+			service.Actions = append(service.Actions, &Action{
+				Name:                   actionName,
+				ResourceTypeReferences: make([]*ResourceTypeReference, 0),
+				ConditionKeys:          make([]string, 0),
+				DependentActions:       make([]string, 0),
+			})
+		}
+		for _, conditionKeyName := range it.ConditionKeys {
+			if !seen[service.Prefix+" "+conditionKeyName] {
+				seen[service.Prefix+" "+conditionKeyName] = true
+				service.ConditionKeys = append(service.ConditionKeys, &ConditionKey{Name: conditionKeyName})
+			}
+		}
+	}
+
+	services := make([]*Service, 0, len(byPrefix))
+	for _, it := range byPrefix {
+		services = append(services, it)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services, nil, nil
+}
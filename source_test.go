@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCrawler(t *testing.T) {
+	for _, spec := range []string{"docs", "docs-dir:testdata/docs", "service-reference:testdata/servicereference", "policies-js:testdata/policies.js"} {
+		crawler, err := newCrawler(spec)
+		assert.NoError(t, err, spec)
+		assert.NotNil(t, crawler, spec)
+	}
+
+	_, err := newCrawler("service-reference:")
+	assert.ErrorContains(t, err, "expected a path after service-reference:")
+	_, err = newCrawler("html")
+	assert.Error(t, err)
+}
+
+func TestCrawlServiceReference(t *testing.T) {
+	services, crawlErrors, err := crawlServiceReference(filepath.Join("testdata", "servicereference"))
+	assert.NoError(t, err)
+	assert.Len(t, crawlErrors, 1)
+	assert.Equal(t, filepath.Join("testdata", "servicereference", "broken.json"), crawlErrors[0].URL)
+
+	assert.Len(t, services, 1)
+	s3 := services[0]
+	assert.Equal(t, "s3", s3.Prefix)
+
+	accessLevels := map[string]string{}
+	for _, it := range s3.Actions {
+		accessLevels[it.Name] = it.AccessLevel
+	}
+	assert.Equal(t, map[string]string{
+		"GetObject":        "Read",
+		"ListBucket":       "List",
+		"PutBucketPolicy":  "Permissions management",
+		"PutObjectTagging": "Tagging",
+	}, accessLevels)
+	assert.Equal(t, []string{"s3:ExistingObjectTag/<key>", "s3:signatureversion"}, s3.Actions[0].ConditionKeys)
+	assert.Equal(t, []*ResourceTypeReference{{Name: "object"}}, s3.Actions[0].ResourceTypeReferences)
+	assert.Equal(t, &ResourceType{Name: "bucket", ARN: "arn:${Partition}:s3:::${BucketName}", ConditionKeys: []string{}}, s3.ResourceTypes[0])
+	assert.Equal(t, &ConditionKey{Name: "s3:prefix", Type: "String"}, s3.ConditionKeys[1])
+
+	// A single file works too
+	services, crawlErrors, err = crawlServiceReference(filepath.Join("testdata", "servicereference", "s3.json"))
+	assert.NoError(t, err)
+	assert.Empty(t, crawlErrors)
+	assert.Len(t, services, 1)
+
+	_, _, err = crawlServiceReference(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestCrawlPolicyGenerator(t *testing.T) {
+	services, crawlErrors, err := crawlPolicyGenerator(filepath.Join("testdata", "policies.js"))
+	assert.NoError(t, err)
+	assert.Empty(t, crawlErrors)
+	assert.Len(t, services, 2)
+
+	// Both versions of Kinesis Analytics use the same prefix
+	kinesis := services[0]
+	assert.Equal(t, "Amazon Kinesis Analytics", kinesis.Name)
+	assert.Equal(t, "kinesisanalytics", kinesis.Prefix)
+	assert.Equal(t, []string{"CreateApplication", "DescribeApplication", "CreateApplicationSnapshot"}, actionNames(kinesis))
+	assert.Len(t, kinesis.ConditionKeys, 2)
+
+	s3 := services[1]
+	assert.Equal(t, "s3", s3.Prefix)
+	assert.Equal(t, []string{"GetObject", "ListBucket", "PutObject"}, actionNames(s3))
+	assert.Equal(t, "aws:RequestTag/${TagKey}", s3.ConditionKeys[0].Name)
+
+	_, _, err = crawlPolicyGenerator(filepath.Join("testdata", "servicereference", "s3.json"))
+	assert.ErrorContains(t, err, "missing app.PolicyEditorConfig=")
+}
+
+func actionNames(service *Service) []string {
+	names := make([]string, 0, len(service.Actions))
+	for _, it := range service.Actions {
+		names = append(names, it.Name)
+	}
+	return names
+}
@@ -0,0 +1 @@
+app.PolicyEditorConfig={"conditionOperators":["StringEquals","StringNotEquals"],"conditionKeys":["aws:CurrentTime","aws:SourceIp"],"serviceMap":{"Amazon Kinesis Analytics":{"StringPrefix":"kinesisanalytics","Actions":["CreateApplication","DescribeApplication"],"ARNFormat":"arn:aws:kinesisanalytics:<region>:<account_ID>:application/<application_name>","ARNRegex":"^arn:aws:kinesisanalytics:.+","conditionKeys":["aws:RequestTag/${TagKey}"],"HasResource":true},"Amazon Kinesis Analytics V2":{"StringPrefix":"kinesisanalytics","Actions":["CreateApplication","CreateApplicationSnapshot"],"ARNFormat":"arn:aws:kinesisanalytics:<region>:<account_ID>:application/<application_name>","ARNRegex":"^arn:aws:kinesisanalytics:.+","conditionKeys":["aws:RequestTag/${TagKey}","kinesisanalytics:VpcId"],"HasResource":true},"Amazon S3":{"StringPrefix":"s3","Actions":["GetObject","ListBucket","PutObject"],"ARNFormat":"arn:aws:s3:::<bucket_name>/<key_name>","ARNRegex":"^arn:aws:s3:::.+","conditionKeys":["aws:RequestTag/${TagKey}","s3:prefix"],"HasResource":true}}};
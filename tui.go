@@ -0,0 +1,637 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+)
+
+const TUI_HELP = "Up/Down choose result, Ctrl-T search mode, Ctrl-S add to cart, Ctrl-P show policy, F1 all keys"
+
+// TUI_KEYS explains every key binding, shown with F1
+var TUI_KEYS = [][]string{
+	{"Up, Down, PageUp, PageDown", "choose a result"},
+	{"Ctrl-T", "switch what the query searches"},
+	{"Tab, Enter", "choose and follow a link, like a dependent action"},
+	{"Alt-Left, Alt-Right", "go back and forward"},
+	{"Backspace", "go back, if the query is empty"},
+	{"Ctrl-O", "show the service of the action"},
+	{"Ctrl-D", "show every action the action depends on"},
+	{"Ctrl-S", "add the action to the cart, or remove it"},
+	{"Ctrl-G", "go to the cart and back, remove actions from it with Delete"},
+	{"Ctrl-P", "show a policy allowing the actions in the cart"},
+	{"Alt-N", "copy the name of the action"},
+	{"Alt-R", "copy the resources the action requires"},
+	{"Alt-S", "copy a statement allowing the action"},
+	{"Alt-P", "copy a policy allowing the actions in the cart"},
+	{"Alt-L", "copy the names of the actions in the cart"},
+	{"F1", "show this help"},
+	{"Ctrl-C", "quit"},
+}
+
+const TUI_MAX_RESULTS = 50
+const TUI_RESULT_LIST_HEIGHT = 8
+const TUI_CART_WIDTH = 40
+
+// tuiMode decides what the query is matched against.
+type tuiMode int
+
+const (
+	// fuzzy match of action names, or wildcard expansion
+	TUI_MODE_ACTION tuiMode = iota
+	// ranked full-text search of action names and descriptions
+	TUI_MODE_TEXT
+	// ARN or resource type, listing the actions that can be scoped to it
+	TUI_MODE_RESOURCE
+	// condition key, listing the actions supporting it
+	TUI_MODE_CONDITION_KEY
+)
+
+var TUI_MODE_LABELS = []string{"Action: ", "Text: ", "Resource: ", "Condition Key: "}
+
+// tuiResult is an entry of the result list. The detail is rendered when the entry is chosen.
+type tuiResult struct {
+	Label string
+	// the action the entry stands for, if any
+	ServiceAction *ServiceAction
+	render        func() string
+}
+
+func newActionResult(serviceAction *ServiceAction) *tuiResult {
+	return &tuiResult{
+		Label:         fmt.Sprintf("%s [::d](%s)[::-]", serviceAction.FullName(), serviceAction.Action.AccessLevel),
+		ServiceAction: serviceAction,
+		render: func() string {
+			return renderBody(serviceAction.Action, serviceAction.Service)
+		},
+	}
+}
+
+type tui struct {
+	services    []*Service
+	actionNames []string
+	searchIndex *SearchIndex
+	// compiles the ARN templates once for the lookups while typing
+	resourceTypeIndex *ResourceTypeIndex
+	conditionKeyIndex *ConditionKeyIndex
+	// the documented names of all condition keys
+	conditionKeyNames []string
+	crawledAt         time.Time
+	opts              *Options
+
+	app        *cview.Application
+	inputField *cview.InputField
+	resultList *cview.List
+	textView   *cview.TextView
+	statusView *cview.TextView
+	cartList   *cview.List
+	mainFlex   *cview.Flex
+
+	mode    tuiMode
+	results []*tuiResult
+	// the IDs of the links in the text view, and the one chosen with Tab
+	links     []string
+	linkIndex int
+	// set while choosing a link, which must not follow it yet
+	choosingLink bool
+	// the action currently shown in the text view, if any
+	current *ServiceAction
+	// the text currently shown in the text view
+	shownText string
+	history   *history
+	// shown in the status bar until the next search, like the outcome of copying
+	notice string
+	// the actions picked for the policy, and the action of every item of the cart list, nil for headings
+	cart      *Cart
+	cartItems []*ServiceAction
+}
+
+func runTUI(services []*Service, crawledAt time.Time, cart *Cart, opts *Options) error {
+	t := &tui{
+		services:          services,
+		actionNames:       buildActionNames(services),
+		searchIndex:       NewSearchIndex(services),
+		resourceTypeIndex: NewResourceTypeIndex(services),
+		conditionKeyIndex: NewConditionKeyIndex(services),
+		conditionKeyNames: allConditionKeyNames(services),
+		crawledAt:         crawledAt,
+		opts:              opts,
+		cart:              cart,
+		history:           &history{},
+	}
+
+	t.app = cview.NewApplication()
+	if t.opts.Terminal != nil {
+		t.opts.Terminal.app = t.app
+	}
+	t.app.EnableMouse(true)
+
+	t.textView = cview.NewTextView()
+	t.textView.SetDynamicColors(true)
+	t.textView.SetRegions(true)
+	t.textView.SetMaxLines(0)
+	t.textView.SetScrollBarVisibility(cview.ScrollBarAuto)
+	t.textView.SetChangedFunc(func() {
+		t.app.Draw()
+	})
+	t.textView.SetHighlightedFunc(func(added, removed, remaining []string) {
+		// Clicking a link highlights it
+		if len(added) > 0 && !t.choosingLink {
+			t.searchActions(added[0])
+		}
+	})
+
+	t.resultList = cview.NewList()
+	t.resultList.ShowSecondaryText(false)
+	t.resultList.SetScrollBarVisibility(cview.ScrollBarAuto)
+	t.resultList.SetChangedFunc(func(index int, item *cview.ListItem) {
+		t.showResult(index)
+	})
+	t.resultList.SetSelectedFunc(func(index int, item *cview.ListItem) {
+		// Clicking a result shouldn't take the focus away from the query for long
+		t.showResult(index)
+		t.app.SetFocus(t.inputField)
+	})
+
+	t.statusView = cview.NewTextView()
+	t.statusView.SetDynamicColors(true)
+
+	t.cartList = cview.NewList()
+	t.cartList.SetTitle("Cart")
+	t.cartList.SetBorder(true)
+	t.cartList.ShowSecondaryText(false)
+	t.cartList.SetScrollBarVisibility(cview.ScrollBarAuto)
+	t.cartList.SetSelectedFunc(func(index int, item *cview.ListItem) {
+		if index < len(t.cartItems) && t.cartItems[index] != nil {
+			t.searchActions(t.cartItems[index].FullName())
+		}
+	})
+	t.cartList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyDelete, tcell.KeyBackspace, tcell.KeyBackspace2:
+			index := t.cartList.GetCurrentItemIndex()
+			if index < len(t.cartItems) && t.cartItems[index] != nil {
+				t.changeCart(t.cart.Remove(t.cartItems[index]))
+			}
+		case tcell.KeyEscape:
+			t.app.SetFocus(t.inputField)
+		default:
+			return event
+		}
+		return nil
+	})
+
+	t.inputField = cview.NewInputField()
+	t.inputField.SetLabel(TUI_MODE_LABELS[t.mode])
+	t.inputField.SetFieldWidth(0)
+	t.inputField.SetChangedFunc(t.search)
+	t.inputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp:
+			t.resultList.Transform(cview.TransformPreviousItem)
+		case tcell.KeyDown:
+			t.resultList.Transform(cview.TransformNextItem)
+		case tcell.KeyPgUp:
+			t.resultList.Transform(cview.TransformPreviousPage)
+		case tcell.KeyPgDn:
+			t.resultList.Transform(cview.TransformNextPage)
+		case tcell.KeyTab:
+			t.chooseNextLink()
+		case tcell.KeyEnter:
+			if t.linkIndex >= 0 && t.linkIndex < len(t.links) {
+				t.searchActions(t.links[t.linkIndex])
+			}
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if len(t.inputField.GetText()) > 0 {
+				return event
+			}
+			t.back()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	t.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyLeft, tcell.KeyRight:
+			if event.Modifiers()&tcell.ModAlt == 0 {
+				return event
+			}
+			if event.Key() == tcell.KeyLeft {
+				t.back()
+			} else {
+				t.forward()
+			}
+			return nil
+		case tcell.KeyRune:
+			if event.Modifiers()&tcell.ModAlt == 0 {
+				return event
+			}
+			switch event.Rune() {
+			case 'p':
+				policy, err := renderPolicy(buildPolicy(t.cart.Actions, t.opts.Placeholders))
+				t.copy("the cart as policy", policy, err)
+				return nil
+			case 'l':
+				t.copy("the cart as list", renderCartList(t.cart), nil)
+				return nil
+			}
+			if t.current == nil {
+				return event
+			}
+			switch event.Rune() {
+			case 'n':
+				t.copy("the name of "+t.current.FullName(), t.current.FullName(), nil)
+			case 'r':
+				resources := policyResources(t.current, t.opts.Placeholders)
+				t.copy("the resources of "+t.current.FullName(), strings.Join(resources, "\n"), nil)
+			case 's':
+				snippet, err := statementSnippet(t.current, t.opts.Placeholders)
+				t.copy("a statement allowing "+t.current.FullName(), snippet, err)
+			default:
+				return event
+			}
+			return nil
+		case tcell.KeyF1:
+			t.history.Push(t.state())
+			t.showText(renderKeys())
+			return nil
+		case tcell.KeyCtrlT:
+			t.setMode((t.mode + 1) % tuiMode(len(TUI_MODE_LABELS)))
+			t.search(t.inputField.GetText())
+			return nil
+		case tcell.KeyCtrlO:
+			if t.current != nil {
+				t.searchActions(t.current.Service.Prefix + ":")
+			}
+			return nil
+		case tcell.KeyCtrlD:
+			if t.current != nil {
+				t.history.Push(t.state())
+				t.showText(renderDependencyClosure(t.current, dependencyClosure(t.current, t.services)))
+			}
+			return nil
+		case tcell.KeyCtrlS:
+			if t.current != nil {
+				t.changeCart(t.cart.Toggle(t.current))
+			}
+			return nil
+		case tcell.KeyCtrlG:
+			if t.app.GetFocus() == t.cartList || len(t.cart.Actions) == 0 {
+				t.app.SetFocus(t.inputField)
+			} else {
+				t.app.SetFocus(t.cartList)
+			}
+			return nil
+		case tcell.KeyCtrlP:
+			policy, err := renderPolicy(buildPolicy(t.cart.Actions, t.opts.Placeholders))
+			if err != nil {
+				policy = err.Error()
+			}
+			t.history.Push(t.state())
+			t.showText(cview.Escape(policy))
+			return nil
+		}
+		return event
+	})
+
+	t.mainFlex = cview.NewFlex()
+	t.mainFlex.AddItem(t.textView, 0, 1, false)
+	t.mainFlex.AddItem(t.cartList, 0, 0, false)
+	t.updateCart()
+
+	flex := cview.NewFlex()
+	flex.SetDirection(cview.FlexRow)
+	flex.AddItem(t.inputField, 1, 0, true)
+	flex.AddItem(t.resultList, TUI_RESULT_LIST_HEIGHT, 0, false)
+	flex.AddItem(t.mainFlex, 0, 1, false)
+	flex.AddItem(t.statusView, 2, 0, false)
+
+	t.app.SetRoot(flex, true)
+	return t.app.Run()
+}
+
+// search fills the result list with the results of the query and shows the first one.
+func (t *tui) search(query string) {
+	t.setResults(t.resultsFor(query))
+	if len(t.notice) > 0 {
+		t.notice = ""
+		t.updateStatus()
+	}
+}
+
+// copy puts the text into the clipboard and tells whether that worked. err is the error of creating the text, if any.
+func (t *tui) copy(description string, text string, err error) {
+	if err == nil {
+		err = t.opts.Clipboard(text)
+	}
+	if err != nil {
+		t.notice = fmt.Sprintf("[red]Couldn't copy %s: %s[white]", description, cview.Escape(err.Error()))
+	} else {
+		t.notice = fmt.Sprintf("Copied %s", description)
+	}
+	t.updateStatus()
+}
+
+func (t *tui) resultsFor(query string) []*tuiResult {
+	results := make([]*tuiResult, 0)
+	// Words still being typed match every word they are the start of
+	partial := len(query) > 0 && !unicode.IsSpace(rune(query[len(query)-1]))
+	query, filter := parseAccessLevelFilter(query)
+
+	if t.mode == TUI_MODE_TEXT {
+		for _, it := range t.searchIndex.Search(query, partial) {
+			if len(results) >= TUI_MAX_RESULTS {
+				break
+			}
+			if filter.Matches(it.ServiceAction.Action.AccessLevel) {
+				results = append(results, newActionResult(it.ServiceAction))
+			}
+		}
+		return results
+	}
+
+	if t.mode == TUI_MODE_RESOURCE {
+		for _, match := range t.resourceTypeIndex.Find(query) {
+			serviceActions := filter.Apply(actionsForResourceType(match))
+			results = append(results, &tuiResult{
+				Label: fmt.Sprintf("[::b]%d actions on %s[::-]", len(serviceActions), match.FullName()),
+				render: func() string {
+					return renderResourceType(match)
+				},
+			})
+			for _, it := range serviceActions {
+				results = append(results, newActionResult(it))
+			}
+		}
+		return results
+	}
+
+	if t.mode == TUI_MODE_CONDITION_KEY {
+		return t.conditionKeyResultsFor(query, filter)
+	}
+
+	if service := findServiceByQuery(query, t.services); service != nil {
+		serviceActions := filter.Apply(expandWildcard(service.Prefix+":*", []*Service{service}))
+		results = append(results, &tuiResult{
+			Label: fmt.Sprintf("[::b]%s[::-] (%d actions)", service.Name, len(serviceActions)),
+			render: func() string {
+				return renderService(service, serviceActions)
+			},
+		})
+		// Same order as the overview
+		accessLevels, groups := groupByAccessLevel(serviceActions)
+		for _, accessLevel := range accessLevels {
+			for _, it := range groups[accessLevel] {
+				results = append(results, newActionResult(it))
+			}
+		}
+		return results
+	}
+
+	if isWildcard(query) {
+		matches := filter.Apply(expandWildcard(query, t.services))
+		results = append(results, &tuiResult{
+			Label: fmt.Sprintf("[::b]%d actions match %s[::-]", len(matches), cview.Escape(query)),
+			render: func() string {
+				return renderWildcard(query, matches)
+			},
+		})
+		for _, it := range matches {
+			results = append(results, newActionResult(it))
+		}
+		return results
+	}
+
+	// Actions spanning several table rows show up once per row
+	seen := map[string]bool{}
+	// The action names are lowercase
+	for _, match := range stringWithBestMatch(strings.ToLower(query), t.actionNames) {
+		if len(results) >= TUI_MAX_RESULTS {
+			break
+		}
+		if seen[match.Target] {
+			continue
+		}
+		seen[match.Target] = true
+		service, actions := lookupByFullActionName(match.Target, t.services)
+		action := mergeActions(actions)
+		if service != nil && action != nil && filter.Matches(action.AccessLevel) {
+			results = append(results, newActionResult(&ServiceAction{Service: service, Action: action}))
+		}
+	}
+	return results
+}
+
+// conditionKeyResultsFor lists the condition keys starting with the query. Once the query names a single condition key,
+// the actions supporting it are listed too.
+func (t *tui) conditionKeyResultsFor(query string, filter *AccessLevelFilter) []*tuiResult {
+	query = strings.TrimSpace(query)
+	results := make([]*tuiResult, 0)
+	if len(query) == 0 {
+		return results
+	}
+
+	names := make([]string, 0)
+	for _, name := range t.conditionKeyNames {
+		if conditionKeyMatches(name, query) {
+			// The query may fill in a placeholder, which is more specific than the documented name
+			names = append(names, query)
+		} else if strings.HasPrefix(strings.ToLower(name), strings.ToLower(query)) {
+			names = append(names, name)
+		}
+	}
+	names = unique(names)
+	if len(names) > TUI_MAX_RESULTS {
+		names = names[:TUI_MAX_RESULTS]
+	}
+
+	lookups := make([]*ConditionKeyLookup, 0, len(names))
+	for _, name := range names {
+		lookup := newConditionKeyLookup(name, t.conditionKeyIndex)
+		actions := make([]*ConditionKeyAction, 0, len(lookup.Actions))
+		for _, it := range lookup.Actions {
+			if filter.Matches(it.AccessLevel) {
+				actions = append(actions, it)
+			}
+		}
+		lookup.Actions = actions
+		lookups = append(lookups, lookup)
+	}
+
+	for _, lookup := range lookups {
+		results = append(results, &tuiResult{
+			Label: fmt.Sprintf("[::b]%s[::-] (%d actions)", cview.Escape(lookup.Name), len(lookup.Actions)),
+			render: func() string {
+				return renderConditionKey(lookup)
+			},
+		})
+	}
+	if len(lookups) == 1 {
+		for _, it := range lookups[0].Actions {
+			results = append(results, newActionResult(it.ServiceAction))
+		}
+	}
+	return results
+}
+
+func (t *tui) setResults(results []*tuiResult) {
+	t.results = results
+	t.resultList.Clear()
+	for _, it := range results {
+		t.resultList.AddItem(cview.NewListItem(it.Label))
+	}
+	t.showResult(0)
+}
+
+func (t *tui) showResult(index int) {
+	t.current = nil
+	if index < 0 || index >= len(t.results) {
+		t.showText("No match")
+		return
+	}
+
+	result := t.results[index]
+	t.current = result.ServiceAction
+	t.showText(result.render())
+}
+
+func (t *tui) showText(text string) {
+	t.shownText = text
+	t.textView.Highlight()
+	t.textView.SetText(text)
+	t.textView.ScrollToBeginning()
+	t.links = regionIDs(text)
+	t.linkIndex = -1
+}
+
+// chooseNextLink highlights the next link of the text view, to be followed with Enter.
+func (t *tui) chooseNextLink() {
+	if len(t.links) == 0 {
+		return
+	}
+	t.linkIndex = (t.linkIndex + 1) % len(t.links)
+	t.choosingLink = true
+	t.textView.Highlight(t.links[t.linkIndex])
+	t.choosingLink = false
+	t.textView.ScrollToHighlight()
+}
+
+// searchActions switches to searching actions and runs the query, like following a link to an action.
+func (t *tui) searchActions(query string) {
+	t.history.Push(t.state())
+	t.setMode(TUI_MODE_ACTION)
+	// Setting the text runs the search
+	t.inputField.SetText(query)
+	t.app.SetFocus(t.inputField)
+}
+
+func (t *tui) setMode(mode tuiMode) {
+	t.mode = mode
+	t.inputField.SetLabel(TUI_MODE_LABELS[t.mode])
+}
+
+func (t *tui) state() *tuiState {
+	scrollRow, _ := t.textView.GetScrollOffset()
+	return &tuiState{
+		mode:        t.mode,
+		query:       t.inputField.GetText(),
+		resultIndex: t.resultList.GetCurrentItemIndex(),
+		text:        t.shownText,
+		scrollRow:   scrollRow,
+		current:     t.current,
+	}
+}
+
+func (t *tui) restore(state *tuiState) {
+	t.setMode(state.mode)
+	t.inputField.SetText(state.query)
+	t.resultList.SetCurrentItem(state.resultIndex)
+	t.current = state.current
+	t.showText(state.text)
+	t.textView.ScrollTo(state.scrollRow, 0)
+}
+
+func (t *tui) back() {
+	if state := t.history.Back(t.state()); state != nil {
+		t.restore(state)
+	}
+}
+
+func (t *tui) forward() {
+	if state := t.history.Forward(t.state()); state != nil {
+		t.restore(state)
+	}
+}
+
+func (t *tui) updateStatus() {
+	maxAge := t.opts.MaxAge
+	if len(t.opts.Snapshot) > 0 || len(t.opts.DataFile) > 0 {
+		// the data was picked explicitly, so refreshing it wouldn't help
+		maxAge = 0
+	}
+	status := fmt.Sprintf("%s | %d in cart", renderDataAge(t.crawledAt, maxAge), len(t.cart.Actions))
+	if len(t.notice) > 0 {
+		status += " | " + t.notice
+	}
+	t.statusView.SetText(status + "\n" + TUI_HELP)
+}
+
+func renderDataAge(crawledAt time.Time, maxAge time.Duration) string {
+	age := time.Since(crawledAt)
+	message := fmt.Sprintf("IAM data crawled %s ago on %s", formatAge(age), crawledAt.Local().Format("2006-01-02"))
+	if maxAge > 0 && age > maxAge {
+		message = fmt.Sprintf("[red]%s, run `iampolicyhelper refresh` to update it[white]", message)
+	}
+	return message
+}
+
+// changeCart shows the cart after it changed. err is the error of saving it, if any.
+func (t *tui) changeCart(err error) {
+	if err != nil {
+		t.notice = fmt.Sprintf("[red]Couldn't save the cart: %s[white]", cview.Escape(err.Error()))
+	}
+	t.updateCart()
+}
+
+// updateCart fills the cart list with the actions of the cart, and hides it while the cart is empty.
+func (t *tui) updateCart() {
+	index := t.cartList.GetCurrentItemIndex()
+	t.cartList.Clear()
+	t.cartItems = make([]*ServiceAction, 0)
+	for _, group := range t.cart.Groups() {
+		t.cartList.AddItem(cview.NewListItem(fmt.Sprintf("[::b]%s[::-] [::d]%s[::-]", group.Service.Prefix, group.AccessLevel)))
+		t.cartItems = append(t.cartItems, nil)
+		for _, it := range group.Actions {
+			t.cartList.AddItem(cview.NewListItem("  " + it.Action.Name))
+			t.cartItems = append(t.cartItems, it)
+		}
+	}
+	if index >= len(t.cartItems) {
+		index = len(t.cartItems) - 1
+	}
+	t.cartList.SetCurrentItem(index)
+
+	if len(t.cart.Actions) > 0 {
+		t.mainFlex.ResizeItem(t.cartList, TUI_CART_WIDTH, 0)
+	} else {
+		t.mainFlex.ResizeItem(t.cartList, 0, 0)
+		if t.app.GetFocus() == t.cartList {
+			t.app.SetFocus(t.inputField)
+		}
+	}
+	t.updateStatus()
+}
+
+func renderKeys() string {
+	lines := []string{"[::b]Keys[::-]", ""}
+	for _, it := range TUI_KEYS {
+		lines = append(lines, fmt.Sprintf("%-28s %s", it[0], it[1]))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTUI() *tui {
+	services := testServices()
+	return &tui{
+		services:          services,
+		actionNames:       buildActionNames(services),
+		searchIndex:       NewSearchIndex(services),
+		resourceTypeIndex: NewResourceTypeIndex(services),
+		conditionKeyIndex: NewConditionKeyIndex(services),
+		conditionKeyNames: allConditionKeyNames(services),
+		opts:              &Options{},
+	}
+}
+
+func resultLabels(results []*tuiResult) []string {
+	labels := []string{}
+	for _, it := range results {
+		labels = append(labels, it.Label)
+	}
+	return labels
+}
+
+func TestTUIResultsFor(t *testing.T) {
+	tui := newTestTUI()
+
+	// GetObject spans two rows but must only be listed once
+	assert.Equal(t, []string{
+		"s3:GetObject [::d](Read)[::-]",
+		"s3:PutObject [::d](Write)[::-]",
+	}, resultLabels(tui.resultsFor("s3:object")))
+
+	results := tui.resultsFor("s3:*Object")
+	assert.Equal(t, []string{
+		"[::b]2 actions match s3:*Object[::-]",
+		"s3:GetObject [::d](Read)[::-]",
+		"s3:PutObject [::d](Write)[::-]",
+	}, resultLabels(results))
+	assert.Nil(t, results[0].ServiceAction)
+	assert.Equal(t, "s3:GetObject", results[1].ServiceAction.FullName())
+
+	assert.Empty(t, tui.resultsFor("nothing matches this"))
+}
+
+func TestTUIResultsForTextMode(t *testing.T) {
+	tui := newTestTUI()
+	tui.mode = TUI_MODE_TEXT
+
+	assert.Equal(t, []string{
+		"iam:PassRole [::d](Write)[::-]",
+		"iam:AttachRolePolicy [::d](Permissions management)[::-]",
+	}, resultLabels(tui.resultsFor("pass a rol")))
+	// A trailing space ends the word
+	assert.Empty(t, tui.resultsFor("rol "))
+}
+
+func TestTUIResultsForResourceMode(t *testing.T) {
+	tui := newTestTUI()
+	tui.mode = TUI_MODE_RESOURCE
+
+	results := tui.resultsFor("arn:aws:s3:::my-bucket")
+	assert.Equal(t, []string{
+		"[::b]1 actions on s3:bucket[::-]",
+		"s3:ListBucket [::d](List)[::-]",
+	}, resultLabels(results))
+	assert.Nil(t, results[0].ServiceAction)
+}
+
+func TestTUIResultsForConditionKeyMode(t *testing.T) {
+	tui := newTestTUI()
+	tui.mode = TUI_MODE_CONDITION_KEY
+
+	assert.Equal(t, []string{
+		"[::b]s3:ExistingObjectTag/<key>[::-] (1 actions)",
+		"[::b]s3:prefix[::-] (1 actions)",
+		"[::b]s3:signatureversion[::-] (1 actions)",
+		"[::b]s3:x-amz-acl[::-] (1 actions)",
+	}, resultLabels(tui.resultsFor("s3:")))
+
+	results := tui.resultsFor("aws:ResourceTag/Owner")
+	assert.Equal(t, []string{
+		"[::b]aws:ResourceTag/Owner[::-] (2 actions)",
+		"iam:AttachRolePolicy [::d](Permissions management)[::-]",
+		"iam:PassRole [::d](Write)[::-]",
+	}, resultLabels(results))
+}
+
+func TestTUIResultsForService(t *testing.T) {
+	tui := newTestTUI()
+
+	results := tui.resultsFor("s3:")
+	assert.Equal(t, []string{
+		"[::b]Amazon S3[::-] (3 actions)",
+		"s3:ListBucket [::d](List)[::-]",
+		"s3:GetObject [::d](Read)[::-]",
+		"s3:PutObject [::d](Write)[::-]",
+	}, resultLabels(results))
+	assert.Nil(t, results[0].ServiceAction)
+}
+
+func TestTUIResultsForAccessLevelFilter(t *testing.T) {
+	tui := newTestTUI()
+
+	results := tui.resultsFor("s3:* -level:write")
+	assert.Equal(t, []string{
+		"[::b]2 actions match s3:*[::-]",
+		"s3:GetObject [::d](Read)[::-]",
+		"s3:ListBucket [::d](List)[::-]",
+	}, resultLabels(results))
+
+	assert.Equal(t, []string{
+		"s3:PutObject [::d](Write)[::-]",
+	}, resultLabels(tui.resultsFor("s3:object level:write")))
+
+	tui.mode = TUI_MODE_TEXT
+	assert.Equal(t, []string{
+		"iam:AttachRolePolicy [::d](Permissions management)[::-]",
+	}, resultLabels(tui.resultsFor(`role level:"permissions management"`)))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/olekukonko/tablewriter"
+)
+
+func isWildcard(s string) bool {
+	return strings.ContainsAny(s, "*?")
+}
+
+// compileWildcard compiles an action pattern the way IAM evaluates it: "*" matches any number of characters, "?"
+// matches exactly one character, and the comparison is case-insensitive.
+func compileWildcard(pattern string) *regexp.Regexp {
+	expr := &strings.Builder{}
+	expr.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// expandWildcard returns every action matching the pattern, sorted by name.
+func expandWildcard(pattern string, services []*Service) []*ServiceAction {
+	re := compileWildcard(strings.TrimSpace(pattern))
+	matches := make([]*ServiceAction, 0)
+	for _, service := range services {
+		// Actions spanning several table rows show up several times
+		actionsByName := map[string][]*Action{}
+		names := make([]string, 0)
+		for _, action := range service.Actions {
+			if re.MatchString(fmt.Sprintf("%s:%s", service.Prefix, action.Name)) {
+				if _, ok := actionsByName[action.Name]; !ok {
+					names = append(names, action.Name)
+				}
+				actionsByName[action.Name] = append(actionsByName[action.Name], action)
+			}
+		}
+		for _, name := range names {
+			matches = append(matches, &ServiceAction{Service: service, Action: mergeActions(actionsByName[name])})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return strings.ToLower(matches[i].FullName()) < strings.ToLower(matches[j].FullName())
+	})
+	return matches
+}
+
+func renderWildcard(pattern string, matches []*ServiceAction) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("[::b]%s[::-] matches no actions", cview.Escape(pattern))
+	}
+
+	accessLevelCounts := map[string]int{}
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"Action", "Access Level"})
+	table.SetColWidth(100)
+	for _, it := range matches {
+		accessLevelCounts[it.Action.AccessLevel]++
+		table.Append([]string{it.FullName(), it.Action.AccessLevel})
+	}
+	table.Render()
+
+	accessLevels := make([]string, 0, len(accessLevelCounts))
+	for accessLevel := range accessLevelCounts {
+		accessLevels = append(accessLevels, accessLevel)
+	}
+	sort.Strings(accessLevels)
+	summary := make([]string, 0, len(accessLevels))
+	for _, accessLevel := range accessLevels {
+		summary = append(summary, fmt.Sprintf("%d %s", accessLevelCounts[accessLevel], accessLevel))
+	}
+
+	return fmt.Sprintf(
+		"[::b]%s[::-] matches %d actions (%s)\n\n%s",
+		cview.Escape(pattern),
+		len(matches),
+		strings.Join(summary, ", "),
+		tableString,
+	)
+}
+
+func runExpand(opts *Options, args []string) error {
+	flags := newFlagSet("expand", "expand [--output text|json] <pattern>")
+	output := flags.String("output", "text", "output format, either text or json")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one pattern, got %d", flags.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	services, err := loadServices(opts)
+	if err != nil {
+		return err
+	}
+
+	matches := expandWildcard(flags.Arg(0), services)
+	if *output == "json" {
+		lookups := make([]*ActionLookup, 0, len(matches))
+		for _, it := range matches {
+			lookups = append(lookups, newActionLookup(it.Service, it.Action))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(lookups)
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, formatForOutput(renderWildcard(flags.Arg(0), matches), os.Stdout))
+	return err
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandWildcard(t *testing.T) {
+	services := testServices()
+	names := func(pattern string) []string {
+		out := []string{}
+		for _, it := range expandWildcard(pattern, services) {
+			out = append(out, it.FullName())
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"s3:GetObject"}, names("s3:get*"))
+	assert.Equal(t, []string{"s3:GetObject", "s3:PutObject"}, names("s3:???Object"))
+	assert.Equal(t, []string{"iam:AttachRolePolicy", "iam:PassRole"}, names("*Role*"))
+	assert.Equal(t, []string{}, names("s3:Get"))
+	assert.Len(t, names("*"), 5)
+
+	// Actions spanning several rows are merged
+	matches := expandWildcard("s3:GetObject*", services)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, []string{"s3:ExistingObjectTag/<key>", "s3:signatureversion"}, matches[0].Action.ConditionKeys)
+}
+
+func TestCompileWildcardQuotesMeta(t *testing.T) {
+	assert.True(t, compileWildcard("s3:Get.bject").MatchString("s3:get.bject"))
+	assert.False(t, compileWildcard("s3:Get.bject").MatchString("s3:GetObject"))
+}